@@ -9,6 +9,20 @@ type WindowRing = Window[*ring.Ring]
 
 type Filter[T any] func(value T) bool
 
+// Folder fold a value into the previous value of the same source and key,
+// it return the value replacing the previous one
+type Folder[T any] func(prev, value T) (T, bool)
+
+type foldKey struct {
+	sid SourceID
+	key string
+}
+
+type foldEntry struct {
+	r *ring.Ring
+	n uint // buffer lines when the entry was added
+}
+
 var NoFilter = func(v any) bool {
 	return v != nil
 }
@@ -17,12 +31,44 @@ type BufferWindow[T any] struct {
 	reader Reader
 	filter Filter[T]
 	parser Parser[T]
-	buffer *Buffer[T]
+	fold   Folder[T]
+	key    func(value T) string
+
+	// last entry of each source and key, continuation values are folded
+	// into it
+	open map[foldKey]foldEntry
+
+	// buffer is the buffer currently displayed, either the live buffer or
+	// a snapshot
+	buffer, live *Buffer[T]
+
+	// live window position saved while a snapshot is displayed
+	liveHead     *ring.Ring
+	liveFollow   bool
+	snapshotName string
+
+	// number of context values displayed before and after matching values
+	before, after int
+
+	// selected is the value selected in the window, nil if none
+	selected *ring.Ring
+
+	// expanded display all values of the buffer, the filtered window
+	// position and selection are saved to go back to them
+	expanded         bool
+	filteredHead     *ring.Ring
+	filteredFollow   bool
+	filteredSelected *ring.Ring
 
 	window       *WindowRing
 	mu           sync.Mutex
 	lock, follow bool
 
+	// matches cache the filter result of each ring until the next refresh,
+	// so context values are known without filtering them again
+	muMatches sync.Mutex
+	matches   map[*ring.Ring]bool
+
 	// for test purpose
 	sync bool
 }
@@ -32,18 +78,33 @@ type BufferWindowOptions[T any] struct {
 	Filter Filter[T]
 	Parser Parser[T]
 	Buffer *Buffer[T]
+
+	// Fold and FoldKey are optional
+	Fold    Folder[T]
+	FoldKey func(value T) string
+
+	// Before and After are the number of values displayed as context
+	// before and after each value matching the filter
+	Before, After int
 }
 
 func NewBufferWindow[T any](size int, opts *BufferWindowOptions[T]) *BufferWindow[T] {
 	window := NewWindow[*ring.Ring](size)
 	return &BufferWindow[T]{
-		filter: opts.Filter,
-		reader: opts.Reader,
-		parser: opts.Parser,
-		buffer: opts.Buffer,
-		follow: true,
-		lock:   false,
-		window: window,
+		filter:  opts.Filter,
+		reader:  opts.Reader,
+		parser:  opts.Parser,
+		fold:    opts.Fold,
+		key:     opts.FoldKey,
+		open:    map[foldKey]foldEntry{},
+		matches: map[*ring.Ring]bool{},
+		before:  opts.Before,
+		after:   opts.After,
+		buffer:  opts.Buffer,
+		live:    opts.Buffer,
+		follow:  true,
+		lock:    false,
+		window:  window,
 	}
 }
 
@@ -54,18 +115,34 @@ func (b *BufferWindow[T]) Readline() (value T, err error) {
 		b.mu.Lock()
 
 		value = b.parser.Parse(sid, line)
-		n := b.buffer.AddValue(value)
+
+		key := foldKey{sid: sid}
+		if b.key != nil {
+			key.key = b.key(value)
+		}
+
+		if folded, ok := b.foldValue(key, value); ok {
+			b.mu.Unlock()
+			return folded, nil
+		}
+
+		n := b.live.AddValue(value)
+		b.open[key] = foldEntry{r: n, n: b.live.Lines()}
+		b.forgetMatch(n) // the ring may have been reused
 
 		switch {
+		case b.buffer != b.live: // snapshot is frozen
 		case b.window.IsEmpty():
-			if b.filterRing(n) {
+			if b.visibleRing(n, map[*ring.Ring]bool{}) {
 				b.window.PushFront(n)
+				b.pushContextBefore(n)
 			}
 		case n == b.window.TailValue(): // buffer has catch windows tail
 			b.window.SlideFront()
 			fallthrough
 		case !b.lock && b.follow, !b.window.IsFull():
-			b.moveFrom(b.window.HeadValue(), 1)
+			// a match also reveal its context before
+			b.moveFrom(b.window.HeadValue(), 1+b.before)
 		}
 
 		b.mu.Unlock()
@@ -74,6 +151,78 @@ func (b *BufferWindow[T]) Readline() (value T, err error) {
 	return
 }
 
+// foldValue fold the value into the last entry of its source, and update
+// the window if the entry now match the filter
+func (b *BufferWindow[T]) foldValue(key foldKey, value T) (folded T, ok bool) {
+	entry, exist := b.open[key]
+	switch {
+	case b.fold == nil, !exist:
+		return
+	case b.live.Lines()-entry.n >= uint(b.live.Size()): // entry has been overwritten
+		delete(b.open, key)
+		return
+	}
+
+	if folded, ok = b.fold(entry.r.Value.(T), value); !ok {
+		return
+	}
+
+	entry.r.Value = folded
+	switch {
+	case b.buffer != b.live: // snapshot is frozen
+	case b.inWindow(entry.r):
+		b.filterRing(entry.r) // update marks
+	case !b.filterRing(entry.r): // still hidden
+	case b.window.IsEmpty():
+		b.window.PushFront(entry.r)
+		b.pushContextBefore(entry.r)
+	default:
+		switch position := b.windowPosition(entry.r); {
+		case position == 0, position < 0 && !b.window.IsFull():
+			// revealed between values of the window, or before them
+			b.refresh()
+		case position > 0:
+			if !b.lock && b.follow || !b.window.IsFull() {
+				b.moveFrom(b.window.HeadValue(), 1+b.before)
+			}
+		}
+	}
+
+	return
+}
+
+// windowPosition locate the given ring outside of the window by walking
+// toward the newest value: -1 if it's older than the window tail, 0 if it's
+// between the window tail and head, 1 if it's newer than the window head
+func (b *BufferWindow[T]) windowPosition(r *ring.Ring) int {
+	tail, head := b.window.TailValue(), b.window.HeadValue()
+	bufferHead := b.buffer.Head()
+
+	position := 1
+	DoRingNext(r, func(n *ring.Ring) bool {
+		switch n {
+		case tail:
+			position = -1
+			return false
+		case head:
+			position = 0
+			return false
+		}
+
+		return n != bufferHead
+	})
+
+	return position
+}
+
+func (b *BufferWindow[T]) inWindow(r *ring.Ring) (ok bool) {
+	b.window.Do(func(w *ring.Ring) bool {
+		ok = w == r
+		return !ok
+	})
+	return
+}
+
 func (b *BufferWindow[T]) WindowSize() (size, length int) {
 	b.mu.Lock()
 	size, length = b.window.Size()
@@ -123,11 +272,162 @@ func (b *BufferWindow[T]) MoveBack() {
 
 func (b *BufferWindow[T]) Clear() {
 	b.mu.Lock()
-	b.buffer.Reset()
+	b.live.Reset()
+	b.open = map[foldKey]foldEntry{}
+	b.buffer, b.snapshotName = b.live, ""
+	b.expanded, b.selected = false, nil
 	b.refresh()
 	b.mu.Unlock()
 }
 
+// Snapshot freeze the window on the given values, lines read in the
+// meantime are still added to the live buffer
+func (b *BufferWindow[T]) Snapshot(name string, values []T) {
+	size := len(values)
+	if size == 0 {
+		size = 1
+	}
+
+	snapshot := NewBuffer[T](size)
+	for _, v := range values {
+		snapshot.AddValue(v)
+	}
+
+	b.mu.Lock()
+	if b.buffer == b.live {
+		b.liveHead, b.liveFollow = b.window.HeadValue(), b.follow
+	}
+
+	b.buffer, b.snapshotName, b.expanded = snapshot, name, false
+	b.selected = nil
+	b.window.Reset()
+	b.refresh()
+	b.mu.Unlock()
+}
+
+// ClearSnapshot go back to the live buffer at the position it was left
+func (b *BufferWindow[T]) ClearSnapshot() {
+	b.mu.Lock()
+	if b.buffer != b.live {
+		b.buffer, b.snapshotName, b.expanded = b.live, "", false
+		b.selected = nil
+		b.window.Reset()
+		if !b.liveFollow && b.liveHead != nil {
+			b.window.PushFront(b.liveHead)
+		}
+		b.refresh()
+	}
+	b.mu.Unlock()
+}
+
+// SnapshotName return the name of the current snapshot, or an empty string
+// if the live buffer is displayed
+func (b *BufferWindow[T]) SnapshotName() (name string) {
+	b.mu.Lock()
+	name = b.snapshotName
+	b.mu.Unlock()
+	return
+}
+
+// SelectAdd move the selected value of the window, older values are
+// positive. The newest value is selected first
+func (b *BufferWindow[T]) SelectAdd(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	rings := []*ring.Ring{}
+	index := -1
+	b.window.Do(func(r *ring.Ring) bool {
+		if r == b.selected {
+			index = len(rings)
+		}
+		rings = append(rings, r)
+		return true
+	})
+
+	switch {
+	case len(rings) == 0:
+		return
+	case index < 0:
+		index = len(rings) - 1
+	default:
+		if index -= n; index < 0 {
+			index = 0
+		} else if index >= len(rings) {
+			index = len(rings) - 1
+		}
+	}
+
+	b.selected = rings[index]
+}
+
+// Expand display the buffer unfiltered, centered on the selected value, or
+// the newest value of the window without selection
+func (b *BufferWindow[T]) Expand() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	center := b.selected
+	if center == nil || !b.inWindow(center) {
+		center = b.window.HeadValue()
+	}
+
+	if b.expanded || center == nil {
+		return false
+	}
+
+	b.filteredHead, b.filteredFollow = b.window.HeadValue(), b.follow
+	b.filteredSelected, b.selected = b.selected, center
+	b.expanded = true
+
+	// center the value, then fill up the window with older values
+	size, _ := b.window.Size()
+	b.window.Reset()
+	b.window.PushFront(center)
+	b.moveFrom(center, -(size / 2))
+	b.moveFrom(center, size-1-size/2)
+	if _, l := b.window.Size(); l < size {
+		b.moveFrom(b.window.TailValue(), l-size)
+	}
+
+	// the expanded view doesn't follow the buffer
+	b.follow = false
+	return true
+}
+
+// Collapse go back to the filtered window at the position and with the
+// selection it was left
+func (b *BufferWindow[T]) Collapse() {
+	b.mu.Lock()
+	if b.expanded {
+		b.expanded, b.selected = false, b.filteredSelected
+		b.window.Reset()
+		if !b.filteredFollow && b.filteredHead != nil {
+			b.window.PushFront(b.filteredHead)
+		}
+		b.refresh()
+		b.follow = b.filteredFollow
+	}
+	b.mu.Unlock()
+}
+
+// DoLive call f with the live buffer under the window lock, so values
+// aren't folded while f read them or update their marks. f must not call
+// the window
+func (b *BufferWindow[T]) DoLive(f func(buffer *Buffer[T])) {
+	b.mu.Lock()
+	f(b.live)
+	b.mu.Unlock()
+}
+
+// Expanded return true if the buffer is displayed unfiltered
+func (b *BufferWindow[T]) Expanded() (expanded bool) {
+	b.mu.Lock()
+	expanded = b.expanded
+	b.mu.Unlock()
+	return
+}
+
 func (b *BufferWindow[T]) Move(n int) {
 	b.mu.Lock()
 
@@ -154,13 +454,14 @@ func (b *BufferWindow[T]) moveFrom(root *ring.Ring, n int) {
 		return
 	}
 
+	seen := map[*ring.Ring]bool{}
 	switch {
 	case n < 0:
 		DoRingPrev(root, func(r *ring.Ring) bool {
 			switch {
 			case r == bufferHead, r.Value == nil:
 				return false
-			case b.filterRing(r):
+			case b.visibleRing(r, seen):
 				b.follow = false
 				b.window.PushBack(r)
 				n++
@@ -180,7 +481,7 @@ func (b *BufferWindow[T]) moveFrom(root *ring.Ring, n int) {
 				return r != bufferHead
 			case r.Value == nil:
 				return false
-			case b.filterRing(r):
+			case b.visibleRing(r, seen):
 				b.window.PushFront(r)
 				n--
 			}
@@ -201,18 +502,23 @@ func (b *BufferWindow[T]) refresh() {
 
 	b.window.Reset()
 
+	b.muMatches.Lock()
+	b.matches = map[*ring.Ring]bool{}
+	b.muMatches.Unlock()
+
 	var wg sync.WaitGroup
 
 	b.follow = false
 
 	wg.Add(1)
 	go func() {
+		seen := map[*ring.Ring]bool{}
 		DoRingPrev(windowHead, func(r *ring.Ring) bool {
 			if r == bufferHead || r.Value == nil {
 				return false
 			}
 
-			if ok := b.filterRing(r); ok {
+			if ok := b.visibleRing(r, seen); ok {
 				b.window.PushBack(r)
 			}
 
@@ -227,12 +533,13 @@ func (b *BufferWindow[T]) refresh() {
 
 	wg.Add(1)
 	go func() {
+		seen := map[*ring.Ring]bool{}
 		DoRingNext(windowHead, func(r *ring.Ring) bool {
 			if r.Value == nil {
 				return false
 			}
 
-			if ok := b.filterRing(r); ok {
+			if ok := b.visibleRing(r, seen); ok {
 				b.window.PushFront(r)
 				if r == bufferHead {
 					b.follow = true
@@ -250,7 +557,7 @@ func (b *BufferWindow[T]) refresh() {
 
 func (b *BufferWindow[T]) Lines() (l uint) {
 	b.mu.Lock()
-	l = b.buffer.Lines()
+	l = b.live.Lines()
 	b.mu.Unlock()
 	return
 }
@@ -276,6 +583,66 @@ func (b *BufferWindow[T]) Do(f func(i int, v T) bool) (size int) {
 
 }
 
+// View call f with the values of the window, oldest first, and whether the
+// window follows the buffer head
+func (b *BufferWindow[T]) View(f func(values []T, follow bool)) {
+	b.mu.Lock()
+	f(b.slice(), b.follow)
+	b.mu.Unlock()
+}
+
+// ValueContext describe how a value of the window is displayed
+type ValueContext struct {
+	// Context is true if the value doesn't match the filter but is
+	// displayed as context of a matching value
+	Context bool
+
+	// Gap is true if values of the buffer have been skipped between the
+	// previous value of the window and this one
+	Gap bool
+}
+
+// ViewContext call f as View does, with the context of each value when
+// context values are enabled or the window is expanded, nil otherwise, and
+// the index of the selected value, -1 if none
+func (b *BufferWindow[T]) ViewContext(f func(values []T, contexts []ValueContext, selected int, follow bool)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	_, l := b.window.Size()
+	values := make([]T, 0, l)
+	selected := -1
+
+	var contexts []ValueContext
+	if b.before != 0 || b.after != 0 || b.expanded {
+		contexts = make([]ValueContext, 0, l)
+	}
+
+	var prev *ring.Ring
+	b.window.Do(func(r *ring.Ring) bool {
+		if len(values) >= l {
+			return false
+		}
+
+		if r == b.selected {
+			selected = len(values)
+		}
+
+		values = append(values, r.Value.(T))
+		if contexts != nil {
+			contexts = append(contexts, ValueContext{
+				Context: !b.matchRing(r),
+				Gap:     prev != nil && prev.Next() != r,
+			})
+		}
+
+		prev = r
+		return true
+	})
+
+	f(values, contexts, selected, b.follow)
+}
+
 func (b *BufferWindow[T]) Slice() (slice []T) {
 	b.mu.Lock()
 	slice = b.slice()
@@ -302,5 +669,94 @@ func (b *BufferWindow[T]) slice() []T {
 }
 
 func (b *BufferWindow[T]) filterRing(r *ring.Ring) (ok bool) {
-	return b.filter(r.Value.(T))
+	ok = b.filter(r.Value.(T))
+
+	b.muMatches.Lock()
+	b.matches[r] = ok
+	b.muMatches.Unlock()
+	return
+}
+
+// matchRing return the cached filter result of the given ring, the filter
+// only run if the ring hasn't been filtered since the last refresh
+func (b *BufferWindow[T]) matchRing(r *ring.Ring) bool {
+	b.muMatches.Lock()
+	ok, exist := b.matches[r]
+	b.muMatches.Unlock()
+
+	if !exist {
+		ok = b.filterRing(r)
+	}
+
+	return ok
+}
+
+func (b *BufferWindow[T]) forgetMatch(r *ring.Ring) {
+	b.muMatches.Lock()
+	delete(b.matches, r)
+	b.muMatches.Unlock()
+}
+
+// visibleRing report if the given ring is displayed: either the window is
+// expanded, it match the filter, or it's in the context of a matching ring.
+// Filter results are cached in seen
+func (b *BufferWindow[T]) visibleRing(r *ring.Ring, seen map[*ring.Ring]bool) bool {
+	switch {
+	case b.expanded:
+		b.filterRing(r) // update marks
+		return true
+	case b.before == 0 && b.after == 0:
+		return b.filterRing(r)
+	}
+
+	match := func(r *ring.Ring) bool {
+		ok, exist := seen[r]
+		if !exist {
+			ok = b.filterRing(r)
+			seen[r] = ok
+		}
+		return ok
+	}
+
+	if match(r) {
+		return true
+	}
+
+	bufferHead := b.buffer.Head()
+
+	// context before a following match
+	for i, n := 0, r; i < b.before && n != bufferHead; i++ {
+		if n = n.Next(); n.Value == nil {
+			break
+		}
+
+		if match(n) {
+			return true
+		}
+	}
+
+	// context after a previous match
+	for i, p := 0, r; i < b.after; i++ {
+		if p = p.Prev(); p == bufferHead || p.Value == nil {
+			break
+		}
+
+		if match(p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pushContextBefore push the context values before the given matching ring
+// at the back of the window
+func (b *BufferWindow[T]) pushContextBefore(r *ring.Ring) {
+	for i, p := 0, r; i < b.before && !b.window.IsFull(); i++ {
+		if p = p.Prev(); p == r || p.Value == nil {
+			return
+		}
+
+		b.window.PushBack(p)
+	}
 }
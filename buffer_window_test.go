@@ -336,7 +336,7 @@ func (r *testReader) ResetLines() {
 }
 
 func (r *testReader) Sources() []File {
-	return []File{{0, "", false}}
+	return []File{{ID: 0, Path: "", Stdin: false}}
 }
 
 func (r *testReader) Readline() (string, SourceID, error) {
@@ -353,3 +353,310 @@ func (p *testParser) Parse(sid SourceID, line string) (i int) {
 	}
 	return
 }
+
+func TestBufferWindowSnapshot(t *testing.T) {
+	filter := func(v int) bool { return true }
+	bw := newTestBufferWindow[int](t, &testParser{}, filter, 100, 5)
+
+	for i := 0; i < 50; i++ {
+		_, err := bw.Readline()
+		require.NoError(t, err)
+	}
+	require.Equal(t, tRange(45, 50), bw.Slice())
+
+	bw.Snapshot("test", []int{3, 2, 1})
+	require.Equal(t, "test", bw.SnapshotName())
+	require.Equal(t, []int{3, 2, 1}, bw.Slice())
+
+	// reading while in snapshot should not update the window
+	_, err := bw.Readline()
+	require.NoError(t, err)
+	require.Equal(t, []int{3, 2, 1}, bw.Slice())
+	require.Equal(t, uint(51), bw.Lines())
+
+	bw.ClearSnapshot()
+	require.Equal(t, "", bw.SnapshotName())
+	require.Equal(t, tRange(46, 51), bw.Slice())
+}
+
+type testLinesReader struct {
+	testReader
+	lines []string
+}
+
+func (r *testLinesReader) Readline() (string, SourceID, error) {
+	index := atomic.AddInt32(&r.index, 1)
+	if int(index) > len(r.lines) {
+		return "", 0, fmt.Errorf("no more lines")
+	}
+	return r.lines[index-1], 0, nil
+}
+
+func TestBufferWindowFold(t *testing.T) {
+	rule, err := NewMultilineRule("indent")
+	require.NoError(t, err)
+
+	filter := NewLineFilter()
+	bw := NewBufferWindow[Line](5, &BufferWindowOptions[Line]{
+		Reader: &testLinesReader{lines: []string{
+			"starting",
+			"Exception in thread main",
+			"    at Foo.bar(Foo.java:42)",
+			"    at NullPointerException.<init>",
+			"done",
+		}},
+		Filter: filter.Match,
+		Parser: &ANSIParser{},
+		Buffer: NewBuffer[Line](10),
+		Fold:   rule.Fold,
+	})
+	bw.sync = true
+
+	filter.Update("NullPointerException")
+	for i := 0; i < 5; i++ {
+		_, err := bw.Readline()
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, uint(3), bw.Lines())
+
+	view := bw.Slice()
+	require.Len(t, view, 1)
+
+	record, ok := view[0].(*Record)
+	require.True(t, ok)
+	require.Len(t, record.Lines(), 3)
+	require.Equal(t, "Exception in thread main", record.Lines()[0].String())
+	require.Equal(t, []Mark{{0, 7, 20}}, testLineMarks(record.Lines()[2]))
+	require.Empty(t, testLineMarks(record.Lines()[0]))
+
+	filter.Update("")
+	bw.Refresh()
+	require.Len(t, bw.Slice(), 3)
+}
+
+func TestBufferWindowFoldFollow(t *testing.T) {
+	rule, err := NewMultilineRule("indent")
+	require.NoError(t, err)
+
+	filter := NewLineFilter()
+	filter.Update("Exception")
+
+	var calls int
+	bw := NewBufferWindow[Line](2, &BufferWindowOptions[Line]{
+		Reader: &testLinesReader{lines: []string{
+			"Exception a",
+			"Exception b",
+			"starting",
+			"    done",
+			"    at Exception",
+		}},
+		Filter: func(l Line) bool {
+			calls++
+			return filter.Match(l)
+		},
+		Parser: &ANSIParser{},
+		Buffer: NewBuffer[Line](10),
+		Fold:   rule.Fold,
+	})
+	bw.sync = true
+
+	for i := 0; i < 3; i++ {
+		_, err := bw.Readline()
+		require.NoError(t, err)
+	}
+
+	// folded without match, filtered once
+	calls = 0
+	_, err = bw.Readline()
+	require.NoError(t, err)
+	require.Equal(t, 1, calls)
+	require.Len(t, bw.Slice(), 2)
+	require.Equal(t, "Exception b", bw.Slice()[1].String())
+
+	// the record now match and slide the window
+	calls = 0
+	_, err = bw.Readline()
+	require.NoError(t, err)
+	require.LessOrEqual(t, calls, 2)
+
+	view := bw.Slice()
+	require.Len(t, view, 2)
+	require.Equal(t, "Exception b", view[0].String())
+	require.Len(t, view[1].(*Record).Lines(), 3)
+}
+
+func TestBufferWindowContext(t *testing.T) {
+	var filtered int
+	newContextWindow := func(height int) *BufferWindow[int] {
+		bw := NewBufferWindow[int](height, &BufferWindowOptions[int]{
+			Reader: &testReader{},
+			Filter: func(v int) bool { filtered++; return v%10 == 0 },
+			Parser: &testParser{},
+			Buffer: NewBuffer[int](100),
+			Before: 2,
+			After:  1,
+		})
+		bw.sync = true
+		return bw
+	}
+
+	readlines := func(bw *BufferWindow[int], n int) {
+		for i := 0; i < n; i++ {
+			_, err := bw.Readline()
+			require.NoError(t, err)
+		}
+	}
+
+	bw := newContextWindow(20)
+
+	// lines are pulled in live as they are read
+	readlines(bw, 9)
+	require.Empty(t, bw.Slice())
+
+	readlines(bw, 22)
+	require.Equal(t, []int{8, 9, 10, 11, 18, 19, 20, 21, 28, 29, 30, 31}, bw.Slice())
+
+	bw.Refresh()
+	require.Equal(t, []int{8, 9, 10, 11, 18, 19, 20, 21, 28, 29, 30, 31}, bw.Slice())
+
+	bw.ViewContext(func(values []int, contexts []ValueContext, selected int, follow bool) {
+		require.Equal(t, -1, selected)
+		require.True(t, follow)
+		require.Len(t, contexts, len(values))
+		require.Equal(t, ValueContext{Context: true}, contexts[0])
+		require.Equal(t, ValueContext{}, contexts[2])
+		require.Equal(t, ValueContext{Context: true, Gap: true}, contexts[4])
+	})
+
+	// values aren't filtered again to be viewed
+	filtered = 0
+	for i := 0; i < 3; i++ {
+		bw.ViewContext(func(_ []int, contexts []ValueContext, _ int, _ bool) {
+			require.Equal(t, ValueContext{Context: true}, contexts[0])
+		})
+	}
+	require.Zero(t, filtered)
+
+	// moving also pull the context
+	bw = newContextWindow(6)
+	readlines(bw, 31)
+	require.Equal(t, []int{20, 21, 28, 29, 30, 31}, bw.Slice())
+	bw.Move(-3)
+	require.Equal(t, []int{11, 18, 19, 20, 21, 28}, bw.Slice())
+	bw.Move(2)
+	require.Equal(t, []int{19, 20, 21, 28, 29, 30}, bw.Slice())
+}
+
+func TestBufferWindowExpand(t *testing.T) {
+	bw := NewBufferWindow[int](5, &BufferWindowOptions[int]{
+		Reader: &testReader{},
+		Filter: func(v int) bool { return v%10 == 0 },
+		Parser: &testParser{},
+		Buffer: NewBuffer[int](100),
+	})
+	bw.sync = true
+
+	readlines := func(n int) {
+		for i := 0; i < n; i++ {
+			_, err := bw.Readline()
+			require.NoError(t, err)
+		}
+	}
+
+	selected := func() (value int) {
+		bw.ViewContext(func(values []int, _ []ValueContext, selected int, _ bool) {
+			require.GreaterOrEqual(t, selected, 0)
+			value = values[selected]
+		})
+		return
+	}
+
+	readlines(31)
+	require.Equal(t, []int{10, 20, 30}, bw.Slice())
+
+	// the newest value is selected first
+	bw.SelectAdd(1)
+	require.Equal(t, 30, selected())
+	bw.SelectAdd(1)
+	require.Equal(t, 20, selected())
+
+	// the selection stay on its value while following
+	readlines(10)
+	require.Equal(t, []int{10, 20, 30, 40}, bw.Slice())
+	require.Equal(t, 20, selected())
+
+	// centered on the selected value
+	require.True(t, bw.Expand())
+	require.True(t, bw.Expanded())
+	require.Equal(t, []int{18, 19, 20, 21, 22}, bw.Slice())
+	require.Equal(t, 20, selected())
+
+	bw.ViewContext(func(values []int, contexts []ValueContext, _ int, _ bool) {
+		require.Len(t, contexts, len(values))
+		require.Equal(t, ValueContext{Context: true}, contexts[0])
+		require.Equal(t, ValueContext{}, contexts[2])
+	})
+
+	require.False(t, bw.Expand())
+
+	// the selection is restored with the filtered view
+	bw.SelectAdd(-1)
+	require.Equal(t, 21, selected())
+	bw.Collapse()
+	require.False(t, bw.Expanded())
+	require.Equal(t, []int{10, 20, 30, 40}, bw.Slice())
+	require.Equal(t, 20, selected())
+
+	// filled up with older values near the newest value
+	bw.SelectAdd(-5)
+	require.Equal(t, 40, selected())
+	require.True(t, bw.Expand())
+	require.Equal(t, []int{37, 38, 39, 40, 41}, bw.Slice())
+
+	// the filtered position is restored
+	bw.Collapse()
+	readlines(30)
+	bw.Move(-1)
+	require.Equal(t, []int{20, 30, 40, 50, 60}, bw.Slice())
+
+	bw.SelectAdd(5)
+	require.Equal(t, 20, selected())
+	require.True(t, bw.Expand())
+	require.Equal(t, []int{18, 19, 20, 21, 22}, bw.Slice())
+
+	bw.Collapse()
+	require.Equal(t, []int{20, 30, 40, 50, 60}, bw.Slice())
+
+	following := func() (follow bool) {
+		bw.ViewContext(func(_ []int, _ []ValueContext, _ int, f bool) { follow = f })
+		return
+	}
+
+	// the expanded view doesn't follow the buffer
+	bw.Move(100)
+	require.True(t, following())
+	bw.SelectAdd(-5)
+	require.True(t, bw.Expand())
+	require.False(t, following())
+	require.Equal(t, []int{67, 68, 69, 70, 71}, bw.Slice())
+	readlines(1)
+	require.Equal(t, []int{67, 68, 69, 70, 71}, bw.Slice())
+
+	// and the filtered view follows it again once collapsed
+	bw.Collapse()
+	require.True(t, following())
+	readlines(20)
+	require.Equal(t, []int{50, 60, 70, 80, 90}, bw.Slice())
+
+	// moving to the bottom of the expanded view doesn't change the position
+	// of the filtered view
+	bw.Move(-1)
+	require.False(t, following())
+	require.True(t, bw.Expand())
+	bw.Move(100)
+	bw.Collapse()
+	require.False(t, following())
+	readlines(20)
+	require.Equal(t, []int{40, 50, 60, 70, 80}, bw.Slice())
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"container/ring"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// number of lines scanned to list the fields of a source
+const columnsScanSize = 1000
+
+// lookupField return the field with the given key, falling back on a case
+// insensitive match
+func (l *StructuredLine) lookupField(key string) *lineField {
+	for _, f := range l.fields {
+		if f.Key == key {
+			return f
+		}
+	}
+
+	for _, f := range l.fields {
+		if strings.EqualFold(f.Key, key) {
+			return f
+		}
+	}
+
+	return nil
+}
+
+// Project render the given fields of the line in the given order, the
+// other fields are hidden. Marks on the displayed values are kept
+func (l *StructuredLine) Project(columns []string) *StructuredLine {
+	pl := &StructuredLine{ANSILine: &ANSILine{sid: l.sid, bgcol: l.bgcol}}
+
+	for _, key := range columns {
+		f := l.lookupField(key)
+		if f == nil {
+			continue
+		}
+
+		if pl.content.Len() > 0 {
+			pl.write(tcell.StyleDefault, " ")
+		}
+
+		pf := &lineField{Key: f.Key, Value: f.Value, quoted: f.quoted, bare: f.bare, off: -1}
+		lkey := strings.ToLower(f.Key)
+		switch {
+		case f.bare:
+			pl.write(structuredKeyStyle, f.Key)
+		case containsString(structuredTimeKeys, lkey):
+			pl.writeValue(pf, structuredTimeStyle, f.Value)
+		case containsString(structuredLevelKeys, lkey):
+			pl.writeValue(pf, structuredLevelStyle(f.Value), strings.ToUpper(f.Value))
+		case containsString(structuredMessageKeys, lkey):
+			pl.writeValue(pf, tcell.StyleDefault, f.Value)
+		default:
+			pl.write(structuredKeyStyle, f.Key)
+			pl.write(structuredEqualStyle, "=")
+			pl.writeValue(pf, tcell.StyleDefault, f.renderValue())
+		}
+
+		pl.fields = append(pl.fields, pf)
+		if f.off < 0 || pf.off < 0 {
+			continue
+		}
+
+		// move the marks of the value
+		for _, m := range l.marks {
+			from, to := m.Off, m.Off+m.Len
+			if from < f.off {
+				from = f.off
+			}
+			if end := f.off + len(f.Value); to > end {
+				to = end
+			}
+			if from < to {
+				pl.marks = append(pl.marks, Mark{N: m.N, Off: pf.off + from - f.off, Len: to - from})
+			}
+		}
+	}
+
+	pl.parseTime()
+	return pl
+}
+
+// sourceFields list the fields of the structured lines of the given
+// source, in order of appearance
+func sourceFields(buffer *Buffer[Line], sid SourceID) []string {
+	seen := map[string]bool{}
+	fields := []string{}
+
+	var n int
+	buffer.DoPrev(func(_ *ring.Ring, l Line) bool {
+		sl, ok := l.(*StructuredLine)
+		if !ok || sl.Source() != sid {
+			return true
+		}
+
+		// keep the fields of the newest lines first
+		keys := []string{}
+		for _, f := range sl.fields {
+			if !seen[f.Key] {
+				seen[f.Key] = true
+				keys = append(keys, f.Key)
+			}
+		}
+		fields = append(fields, keys...)
+
+		n++
+		return n < columnsScanSize
+	})
+
+	return fields
+}
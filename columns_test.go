@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStructuredLineProject(t *testing.T) {
+	p := &LogfmtParser{NoColor: true, Fallback: &RawParser{}}
+	l := p.Parse(0, `time=2022-01-02T15:04:05Z level=info msg="user login" user=bob trace_id=abc123 host=srv1`)
+	sl := l.(*StructuredLine)
+
+	pl := sl.Project([]string{"user", "msg", "missing", "level"})
+	require.Equal(t, "user=bob user login INFO", pl.String())
+
+	value, off, ok := pl.Field("user")
+	require.True(t, ok)
+	require.Equal(t, "bob", pl.String()[off:off+len(value)])
+
+	_, _, ok = pl.Field("trace_id")
+	require.False(t, ok)
+
+	// hidden fields are still filtered on the original line
+	f := NewLineFilter()
+	f.Update("abc123")
+	require.True(t, f.Match(l))
+
+	// marks on displayed values are moved
+	f.Update("bob")
+	require.True(t, f.Match(l))
+	pl = sl.Project([]string{"user"})
+	require.Equal(t, []Mark{{N: 0, Off: 5, Len: 3}}, pl.marks)
+
+	// the time is kept
+	ts, _, _ := sl.Project([]string{"msg", "time"}).Time()
+	require.False(t, ts.IsZero())
+}
+
+func TestSourceFields(t *testing.T) {
+	buffer := NewBuffer[Line](10)
+	p := &JSONParser{NoColor: true, Fallback: &RawParser{}}
+	buffer.AddValue(p.Parse(1, `{"msg": "a", "user": "bob"}`))
+	buffer.AddValue(p.Parse(2, `{"other": "source"}`))
+	buffer.AddValue(p.Parse(1, "not json"))
+	buffer.AddValue(p.Parse(1, `{"msg": "b", "trace": "x"}`))
+
+	require.Equal(t, []string{"msg", "trace", "user"}, sourceFields(buffer, 1))
+	require.Equal(t, []string{"other"}, sourceFields(buffer, 2))
+}
+
+func TestColumnPicker(t *testing.T) {
+	c := NewColumnPickerComponent(&testPrinter{})
+
+	c.Open(File{}, []string{"time", "msg", "trace", "user"}, nil)
+	require.Equal(t, []string{"time", "msg", "trace", "user"}, c.Columns())
+
+	// hide trace, move user on top
+	c.CursorAdd(-2)
+	c.Toggle()
+	c.CursorAdd(-1)
+	c.Move(3)
+	require.Equal(t, []string{"user", "time", "msg"}, c.Columns())
+
+	c.Open(File{}, []string{"time", "msg", "trace", "user"}, []string{"msg", "time"})
+	require.Equal(t, []string{"msg", "time"}, c.Columns())
+	require.Equal(t, []pickerField{{"msg", true}, {"time", true}, {"trace", false}, {"user", false}}, c.fields)
+}
+
+func TestColumnsFile(t *testing.T) {
+	dir := t.TempDir()
+	config := "# hand written\n[columns]\n\"db.log\" = [\"msg\"]\n"
+	cfg := &LoonConfig{
+		ConfigFile:  filepath.Join(dir, "loonrc"),
+		ColumnsFile: filepath.Join(dir, "state", "columns"),
+	}
+	require.NoError(t, os.WriteFile(cfg.ConfigFile, []byte(config), 0o644))
+	require.NoError(t, loadConfigFile(cfg))
+	require.NoError(t, loadColumnsFile(cfg))
+
+	api, db := NewFile("/var/log/api.log", false), NewFile("db.log", false)
+	require.Nil(t, sourceColumns(cfg, api))
+	require.Equal(t, []string{"msg"}, sourceColumns(cfg, db))
+
+	require.NoError(t, saveColumnsFile(cfg, api, []string{"time", "msg"}))
+	require.Equal(t, []string{"time", "msg"}, sourceColumns(cfg, api))
+
+	// reset over the config file display all fields
+	require.NoError(t, saveColumnsFile(cfg, db, nil))
+	require.Nil(t, sourceColumns(cfg, db))
+
+	// the config file is untouched
+	content, err := os.ReadFile(cfg.ConfigFile)
+	require.NoError(t, err)
+	require.Equal(t, config, string(content))
+
+	loaded := &LoonConfig{ConfigFile: cfg.ConfigFile, ColumnsFile: cfg.ColumnsFile}
+	require.NoError(t, loadConfigFile(loaded))
+	require.NoError(t, loadColumnsFile(loaded))
+	require.Equal(t, map[string][]string{"api.log": {"time", "msg"}, "db.log": {}}, loaded.SavedColumns)
+	require.Nil(t, sourceColumns(loaded, db))
+
+	// removed when reset
+	require.NoError(t, saveColumnsFile(loaded, api, nil))
+	require.NoError(t, loadColumnsFile(loaded))
+	require.Equal(t, map[string][]string{"db.log": {}}, loaded.SavedColumns)
+	require.Nil(t, sourceColumns(loaded, api))
+}
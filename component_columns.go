@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+type pickerField struct {
+	Key     string
+	Visible bool
+}
+
+// ColumnPickerComponent let the user choose and order the fields displayed
+// for a source
+type ColumnPickerComponent struct {
+	printer Printer
+
+	muPicker sync.RWMutex
+	open     bool
+	source   File
+	fields   []pickerField
+	cursor   int
+	err      error
+}
+
+func NewColumnPickerComponent(p Printer) *ColumnPickerComponent {
+	return &ColumnPickerComponent{printer: p}
+}
+
+// Open open the picker on the given fields of the source, the displayed
+// columns come first in their order, then the hidden fields. No columns
+// means all fields are displayed
+func (c *ColumnPickerComponent) Open(source File, fields, columns []string) {
+	c.muPicker.Lock()
+	defer c.muPicker.Unlock()
+
+	c.open, c.source, c.cursor, c.err = true, source, 0, nil
+	c.fields = make([]pickerField, 0, len(fields)+len(columns))
+	for _, key := range columns {
+		c.fields = append(c.fields, pickerField{Key: key, Visible: true})
+	}
+
+	for _, key := range fields {
+		if !containsString(columns, key) {
+			c.fields = append(c.fields, pickerField{Key: key, Visible: len(columns) == 0})
+		}
+	}
+}
+
+func (c *ColumnPickerComponent) Close() {
+	c.muPicker.Lock()
+	c.open = false
+	c.muPicker.Unlock()
+}
+
+func (c *ColumnPickerComponent) IsOpen() (open bool) {
+	c.muPicker.RLock()
+	open = c.open
+	c.muPicker.RUnlock()
+	return
+}
+
+// Source return the source being edited
+func (c *ColumnPickerComponent) Source() (f File) {
+	c.muPicker.RLock()
+	f = c.source
+	c.muPicker.RUnlock()
+	return
+}
+
+func (c *ColumnPickerComponent) SetErr(err error) {
+	c.muPicker.Lock()
+	c.err = err
+	c.muPicker.Unlock()
+}
+
+// CursorAdd move the cursor, up is positive as in the file component
+func (c *ColumnPickerComponent) CursorAdd(y int) {
+	c.muPicker.Lock()
+	c.cursor = c.clamp(c.cursor - y)
+	c.muPicker.Unlock()
+}
+
+// Toggle show or hide the field under the cursor
+func (c *ColumnPickerComponent) Toggle() {
+	c.muPicker.Lock()
+	if c.cursor < len(c.fields) {
+		c.fields[c.cursor].Visible = !c.fields[c.cursor].Visible
+	}
+	c.muPicker.Unlock()
+}
+
+// Move move the field under the cursor, up is positive
+func (c *ColumnPickerComponent) Move(y int) {
+	c.muPicker.Lock()
+	if to := c.clamp(c.cursor - y); to != c.cursor && c.cursor < len(c.fields) {
+		field := c.fields[c.cursor]
+		c.fields = append(c.fields[:c.cursor], c.fields[c.cursor+1:]...)
+		c.fields = append(c.fields[:to], append([]pickerField{field}, c.fields[to:]...)...)
+		c.cursor = to
+	}
+	c.muPicker.Unlock()
+}
+
+func (c *ColumnPickerComponent) clamp(cursor int) int {
+	if cursor >= len(c.fields) {
+		cursor = len(c.fields) - 1
+	}
+
+	if cursor < 0 {
+		return 0
+	}
+	return cursor
+}
+
+// Columns return the visible fields in their order
+func (c *ColumnPickerComponent) Columns() []string {
+	c.muPicker.RLock()
+	defer c.muPicker.RUnlock()
+
+	columns := []string{}
+	for _, f := range c.fields {
+		if f.Visible {
+			columns = append(columns, f.Key)
+		}
+	}
+
+	return columns
+}
+
+func (c *ColumnPickerComponent) Redraw(x, y, width, height int) {
+	c.muPicker.RLock()
+	defer c.muPicker.RUnlock()
+
+	if height == 0 {
+		return
+	}
+
+	title := fmt.Sprintf("columns of %s: space toggle, alt+up/down move, enter save, backspace reset, esc close", c.source.Path)
+	if c.err != nil {
+		title = c.err.Error()
+	}
+
+	style := tcell.StyleDefault.Foreground(tcell.ColorDarkCyan).Bold(true)
+	if c.err != nil {
+		style = tcell.StyleDefault.Foreground(tcell.ColorRed)
+	}
+	fillUpLine(c.printer, c.printer.Print(x, y, style, title), y, width, tcell.StyleDefault)
+
+	// keep the cursor visible
+	var offset int
+	if rows := height - 1; c.cursor >= rows {
+		offset = c.cursor - rows + 1
+	}
+
+	for i := 1; i < height; i++ {
+		indexy := y + i
+		index := offset + i - 1
+		if index >= len(c.fields) {
+			c.printer.Print(x, indexy, tcell.StyleDefault, "~")
+			fillUpLine(c.printer, x+1, indexy, width, tcell.StyleDefault)
+			continue
+		}
+
+		f := c.fields[index]
+		check := "[ ] "
+		if f.Visible {
+			check = "[x] "
+		}
+
+		style := tcell.StyleDefault
+		if index == c.cursor {
+			style = style.Reverse(true)
+		}
+
+		xoffset := c.printer.Print(x, indexy, style, check+f.Key)
+		fillUpLine(c.printer, xoffset, indexy, width, tcell.StyleDefault)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 )
@@ -27,12 +28,26 @@ type FileComponent struct {
 	multisources bool
 	sources      map[SourceID]*sourceFile
 	sourcesize   int
+
+	timeDisplay TimeDisplay
+	collapsed   bool
+
+	// table is true when all sources are CSV or TSV tables, they are
+	// printed in aligned columns and scrolled by column
+	table bool
+
+	// columns are the fields displayed for structured lines of each source
+	columns map[SourceID][]string
 }
 
 func NewFileComponent(lcfg *LoonConfig, print Printer, sources []File, in *Input, bw *BufferWindowLine) *FileComponent {
 	smap := make(map[SourceID]*sourceFile)
+	columns := make(map[SourceID][]string)
 	var maxNameSize int
+	table := len(sources) > 0
 	for _, f := range sources {
+		table = table && isTableParser(sourceParserName(lcfg, f))
+
 		name := filepath.Base(f.Path)
 		if len(name) > maxNameSize {
 			maxNameSize = len(name)
@@ -53,6 +68,9 @@ func NewFileComponent(lcfg *LoonConfig, print Printer, sources []File, in *Input
 
 		smap[f.ID] = sf
 
+		if cols := sourceColumns(lcfg, f); len(cols) > 0 {
+			columns[f.ID] = cols
+		}
 	}
 
 	formatmask := fmt.Sprintf("%%-%ds | ", maxNameSize)
@@ -65,6 +83,8 @@ func NewFileComponent(lcfg *LoonConfig, print Printer, sources []File, in *Input
 		bw:           bw,
 		multisources: len(sources) > 1,
 		sources:      smap,
+		table:        table,
+		columns:      columns,
 	}
 }
 
@@ -76,6 +96,12 @@ func (f *FileComponent) CursorAdd(y int) {
 
 func (f *FileComponent) OffsetAdd(x int) {
 	f.muPosition.Lock()
+	if f.table {
+		// one column per step
+		if columns := x / 2; columns != 0 {
+			x = columns
+		}
+	}
 	f.cursorX += x
 	f.muPosition.Unlock()
 }
@@ -105,6 +131,61 @@ func (f *FileComponent) MoveAdd(x, y int) {
 	f.muPosition.Unlock()
 }
 
+// NextTimeDisplay cycle between original, local, UTC and relative
+// timestamps
+func (f *FileComponent) NextTimeDisplay() TimeDisplay {
+	f.muPosition.Lock()
+	f.timeDisplay = (f.timeDisplay + 1) % timeDisplayCount
+	display := f.timeDisplay
+	f.muPosition.Unlock()
+	return display
+}
+
+func (f *FileComponent) TimeDisplay() (display TimeDisplay) {
+	f.muPosition.RLock()
+	display = f.timeDisplay
+	f.muPosition.RUnlock()
+	return
+}
+
+// ToggleCollapsed collapse or expand multiline records
+func (f *FileComponent) ToggleCollapsed() {
+	f.muPosition.Lock()
+	f.collapsed = !f.collapsed
+	f.muPosition.Unlock()
+}
+
+// SetColumns set the fields displayed for the structured lines of the given
+// source, all fields are displayed without columns
+func (f *FileComponent) SetColumns(sid SourceID, columns []string) {
+	f.muPosition.Lock()
+	if len(columns) == 0 {
+		delete(f.columns, sid)
+	} else {
+		f.columns[sid] = columns
+	}
+	f.muPosition.Unlock()
+}
+
+func (f *FileComponent) Columns(sid SourceID) (columns []string) {
+	f.muPosition.RLock()
+	columns = f.columns[sid]
+	f.muPosition.RUnlock()
+	return
+}
+
+// project render structured lines with the columns of their source, the
+// line is kept untouched so hidden fields are still filtered
+func (f *FileComponent) project(l Line) Line {
+	if sl, ok := l.(*StructuredLine); ok {
+		if columns, ok := f.columns[l.Source()]; ok {
+			return sl.Project(columns)
+		}
+	}
+
+	return l
+}
+
 func (f *FileComponent) updateCursorX(max int) (offset int) {
 	switch {
 	case f.cursorX < 0, max < 0:
@@ -133,6 +214,227 @@ func (f *FileComponent) printSource(sid SourceID, x, y, offset int) (int, int) {
 	return x, offset - len(s.name)
 }
 
+// printTime print the normalized timestamp of the line as a column, and
+// return the offset of the line with its leading timestamp skipped
+func (f *FileComponent) printTime(line Line, x, y, offset int, now time.Time) (int, int) {
+	t, toff, tlen := line.Time()
+	column := fmt.Sprintf("%-*s ", f.timeDisplay.Width(), f.timeDisplay.Format(t, now))
+
+	switch {
+	case offset < 0:
+		x = f.printer.Print(x, y, structuredTimeStyle, column)
+	case offset < len(column):
+		x = f.printer.Print(x, y, structuredTimeStyle, column[offset:])
+	}
+
+	offset -= len(column)
+	if t.IsZero() || toff != 0 {
+		return x, offset
+	}
+
+	if offset < 0 {
+		offset = 0
+	}
+
+	// the original timestamp is replaced by the column
+	skip := tlen
+	if str := line.String(); skip < len(str) && str[skip] == ' ' {
+		skip++
+	}
+
+	return x, offset + skip
+}
+
+// divider printed between groups of context lines
+const contextDivider = "--"
+
+var contextDividerStyle = tcell.StyleDefault.Foreground(tcell.ColorGray)
+
+type fileRow struct {
+	line Line
+
+	// number of lines folded under a collapsed record
+	folded int
+
+	// context is true for context lines around matches, divider rows
+	// separate groups of context lines and have no line
+	context, divider bool
+
+	// selected is true for the rows of the selected line
+	selected bool
+
+	// record is the expanded record of the row, sub the index of the row in
+	// the lines of the record
+	record MultiLine
+	sub    int
+}
+
+// rows split expanded records into rows, when there is more rows than the
+// height, the newest rows are kept while following the buffer, the oldest
+// otherwise. The first line of a record cut at the top is always kept, with
+// the number of its hidden lines. Contexts are optional, selected is the
+// index of the selected line or -1
+func (f *FileComponent) rows(lines []Line, contexts []ValueContext, selected, height int, follow bool) []fileRow {
+	rows := make([]fileRow, 0, len(lines))
+	for i, l := range lines {
+		// table headers are pinned on top of the rows
+		if tl, ok := l.(*TableLine); ok && tl.IsHeader() {
+			continue
+		}
+
+		selected := i == selected
+
+		var context bool
+		if i < len(contexts) {
+			if contexts[i].Gap {
+				rows = append(rows, fileRow{divider: true})
+			}
+			context = contexts[i].Context
+		}
+
+		ml, ok := l.(MultiLine)
+		if !ok {
+			rows = append(rows, fileRow{line: l, context: context, selected: selected})
+			continue
+		}
+
+		sublines := ml.Lines()
+		if f.collapsed {
+			rows = append(rows, fileRow{line: l, folded: len(sublines) - 1, context: context, selected: selected})
+			continue
+		}
+
+		for sub, sl := range sublines {
+			rows = append(rows, fileRow{line: sl, context: context, selected: selected, record: ml, sub: sub})
+		}
+	}
+
+	if len(rows) <= height {
+		return rows
+	}
+
+	if !follow {
+		return rows[:height]
+	}
+
+	rows = rows[len(rows)-height:]
+	if first := rows[0]; first.sub > 0 {
+		rows[0] = fileRow{line: first.record, folded: first.sub, context: first.context, selected: first.selected}
+	}
+
+	return rows
+}
+
+// printFolded print the number of folded lines after a collapsed record
+func (f *FileComponent) printFolded(row fileRow, x, y, width, offset int) {
+	if offset < 0 {
+		offset = 0
+	}
+
+	first := row.line.(MultiLine).Lines()[0]
+	if x += first.Len() - offset; x < width {
+		style := tcell.StyleDefault.Foreground(tcell.ColorGray)
+		f.printer.Print(x, y, style, fmt.Sprintf(" [+%d]", row.folded))
+	}
+}
+
+// tableWidths compute the width of each column from the header and the
+// visible rows
+func tableWidths(header *TableLine, rows []fileRow) []int {
+	widths := []int{}
+	measure := func(l *TableLine) {
+		for i := 0; i < l.Columns(); i++ {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+
+			if w := l.CellWidth(i); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+
+	if header != nil {
+		measure(header)
+	}
+
+	for _, row := range rows {
+		if tl, ok := row.line.(*TableLine); ok {
+			measure(tl)
+		}
+	}
+
+	return widths
+}
+
+// redrawTable print the header of the table pinned on the first line, then
+// the rows in aligned columns starting at the column offset, with their
+// context as the other lines
+func (f *FileComponent) redrawTable(x, y, width, height int) int {
+	var size int
+	now := time.Now()
+	f.bw.ViewContext(func(lines []Line, contexts []ValueContext, selected int, follow bool) {
+		rows := f.rows(lines, contexts, selected, height-1, follow)
+
+		var header *TableLine
+		for _, row := range rows {
+			if tl, ok := row.line.(*TableLine); ok {
+				header = tl.Header()
+				break
+			}
+		}
+
+		widths := tableWidths(header, rows)
+		col := f.updateCursorX(len(widths) - 1)
+
+		printRow := func(row fileRow, y int) {
+			if row.divider {
+				xoffset := f.printer.Print(x, y, contextDividerStyle, contextDivider)
+				fillUpLine(f.printer, xoffset, y, width, tcell.StyleDefault)
+				return
+			}
+
+			sx := x
+			if f.multisources {
+				sx, _ = f.printSource(row.line.Source(), x, y, 0)
+			}
+
+			if f.timeDisplay != TimeDisplayOriginal {
+				sx, _ = f.printTime(row.line, sx, y, -1, now)
+			}
+
+			printer := f.printer
+			if row.context {
+				printer = &dimPrinter{printer}
+			}
+
+			if row.selected {
+				printer = &selectedPrinter{printer}
+			}
+
+			if tl, ok := row.line.(*TableLine); ok {
+				tl.PrintColumns(printer, sx, y, width, widths, col)
+			} else {
+				row.line.Print(printer, sx, y, width, 0)
+			}
+		}
+
+		if header != nil {
+			printRow(fileRow{line: header}, y)
+		} else {
+			fillUpLine(f.printer, x, y, width, tcell.StyleDefault)
+		}
+
+		for i, row := range rows {
+			printRow(row, y+i+1)
+		}
+
+		size = len(rows) + 1
+	})
+
+	return size
+}
+
 func (f *FileComponent) Redraw(x, y, width, height int) {
 	if height == 0 || width == 0 {
 		return
@@ -140,6 +442,21 @@ func (f *FileComponent) Redraw(x, y, width, height int) {
 
 	f.muPosition.Lock()
 
+	if f.table && height > 1 {
+		f.bw.Resize(height - 1)
+		f.moveBufferCursor()
+
+		// fillup empty lines
+		for size := f.redrawTable(x, y, width, height); size < height; size++ {
+			indexy := size + y
+			f.printer.Print(x, indexy, tcell.StyleDefault, "~")
+			fillUpLine(f.printer, x+1, indexy, width, tcell.StyleDefault)
+		}
+
+		f.muPosition.Unlock()
+		return
+	}
+
 	f.bw.Resize(height)
 
 	offy := f.moveBufferCursor()
@@ -151,17 +468,47 @@ func (f *FileComponent) Redraw(x, y, width, height int) {
 		offy, f.cursorY = maxc, maxc
 	}
 
-	size := f.bw.Do(func(i int, l Line) bool {
-		indexy := i + y
-		line := lines[i]
-
-		sx, soffset := x, offx
-		if f.multisources {
-			sx, soffset = f.printSource(line.Source(), x, indexy, offx)
+	now := time.Now()
+	var size int
+	f.bw.ViewContext(func(lines []Line, contexts []ValueContext, selected int, follow bool) {
+		rows := f.rows(lines, contexts, selected, height, follow)
+		for i, row := range rows {
+			indexy := i + y
+			if row.divider {
+				xoffset := f.printer.Print(x, indexy, contextDividerStyle, contextDivider)
+				fillUpLine(f.printer, xoffset, indexy, width, tcell.StyleDefault)
+				continue
+			}
+
+			if row.folded == 0 {
+				row.line = f.project(row.line)
+			}
+
+			sx, soffset := x, offx
+			if f.multisources {
+				sx, soffset = f.printSource(row.line.Source(), x, indexy, offx)
+			}
+
+			if f.timeDisplay != TimeDisplayOriginal {
+				sx, soffset = f.printTime(row.line, sx, indexy, soffset, now)
+			}
+
+			printer := f.printer
+			if row.context {
+				printer = &dimPrinter{printer}
+			}
+
+			if row.selected {
+				printer = &selectedPrinter{printer}
+			}
+
+			row.line.Print(printer, sx, indexy, width, soffset)
+			if row.folded > 0 {
+				f.printFolded(row, sx, indexy, width, soffset)
+			}
 		}
 
-		line.Print(f.printer, sx, indexy, width, soffset)
-		return true
+		size = len(rows)
 	})
 
 	// fillup empty lines
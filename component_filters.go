@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// savedFilterName return the name of the saved filter of the given query,
+// an empty string if the query isn't saved
+func savedFilterName(filters []SavedFilter, query string) string {
+	if query == "" {
+		return ""
+	}
+
+	for _, f := range filters {
+		if f.Query == query {
+			return f.Name
+		}
+	}
+
+	return ""
+}
+
+// savedFilterByKey return the saved filter bound to the given function key
+func savedFilterByKey(filters []SavedFilter, key tcell.Key) (SavedFilter, bool) {
+	for _, f := range filters {
+		if f.Key > 0 && tcell.KeyF1+tcell.Key(f.Key-1) == key {
+			return f, true
+		}
+	}
+
+	return SavedFilter{}, false
+}
+
+// FilterMenuComponent list the saved filters to pick one
+type FilterMenuComponent struct {
+	printer Printer
+	filters []SavedFilter
+
+	muMenu sync.RWMutex
+	open   bool
+	cursor int
+}
+
+func NewFilterMenuComponent(p Printer, filters []SavedFilter) *FilterMenuComponent {
+	return &FilterMenuComponent{printer: p, filters: filters}
+}
+
+// Open open the menu with the cursor on the filter of the current query
+func (c *FilterMenuComponent) Open(query string) {
+	c.muMenu.Lock()
+	c.open, c.cursor = true, 0
+	for i, f := range c.filters {
+		if f.Query == query {
+			c.cursor = i
+			break
+		}
+	}
+	c.muMenu.Unlock()
+}
+
+func (c *FilterMenuComponent) Close() {
+	c.muMenu.Lock()
+	c.open = false
+	c.muMenu.Unlock()
+}
+
+func (c *FilterMenuComponent) IsOpen() (open bool) {
+	c.muMenu.RLock()
+	open = c.open
+	c.muMenu.RUnlock()
+	return
+}
+
+// CursorAdd move the cursor, up is positive as in the file component
+func (c *FilterMenuComponent) CursorAdd(y int) {
+	c.muMenu.Lock()
+	if c.cursor -= y; c.cursor >= len(c.filters) {
+		c.cursor = len(c.filters) - 1
+	}
+	if c.cursor < 0 {
+		c.cursor = 0
+	}
+	c.muMenu.Unlock()
+}
+
+// Selected return the filter under the cursor
+func (c *FilterMenuComponent) Selected() (SavedFilter, bool) {
+	c.muMenu.RLock()
+	defer c.muMenu.RUnlock()
+
+	if c.cursor < len(c.filters) {
+		return c.filters[c.cursor], true
+	}
+
+	return SavedFilter{}, false
+}
+
+func (c *FilterMenuComponent) Redraw(x, y, width, height int) {
+	c.muMenu.RLock()
+	defer c.muMenu.RUnlock()
+
+	if height == 0 {
+		return
+	}
+
+	title := "filters: enter apply, ctrl+f close"
+	if len(c.filters) == 0 {
+		title = "no filters, add them in the [filters] table of the config file"
+	}
+
+	style := tcell.StyleDefault.Foreground(tcell.ColorDarkCyan).Bold(true)
+	fillUpLine(c.printer, c.printer.Print(x, y, style, title), y, width, tcell.StyleDefault)
+
+	var namesize int
+	for _, f := range c.filters {
+		if w := runewidth.StringWidth(f.Name); w > namesize {
+			namesize = w
+		}
+	}
+
+	// keep the cursor visible
+	var offset int
+	if rows := height - 1; c.cursor >= rows {
+		offset = c.cursor - rows + 1
+	}
+
+	for i := 1; i < height; i++ {
+		indexy := y + i
+		index := offset + i - 1
+		if index >= len(c.filters) {
+			c.printer.Print(x, indexy, tcell.StyleDefault, "~")
+			fillUpLine(c.printer, x+1, indexy, width, tcell.StyleDefault)
+			continue
+		}
+
+		f := c.filters[index]
+		var key string
+		if f.Key > 0 {
+			key = fmt.Sprintf("F%d", f.Key)
+		}
+
+		style := tcell.StyleDefault
+		if index == c.cursor {
+			style = style.Reverse(true)
+		}
+
+		// names are padded to their display width
+		pad := strings.Repeat(" ", namesize-runewidth.StringWidth(f.Name))
+		xoffset := c.printer.Print(x, indexy, style, fmt.Sprintf("%-4s %s%s", key, f.Name, pad))
+		xoffset = c.printer.Print(xoffset, indexy, tcell.StyleDefault, " ")
+		xoffset = c.printer.Print(xoffset, indexy, tcell.StyleDefault.Foreground(tcell.ColorGray), f.Query)
+		fillUpLine(c.printer, xoffset, indexy, width, tcell.StyleDefault)
+	}
+}
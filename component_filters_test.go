@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSavedFiltersConfig(t *testing.T) {
+	cfg := &LoonConfig{ConfigFile: filepath.Join(t.TempDir(), "loonrc")}
+	content := `
+nocolor = true
+
+[filters]
+"slow requests" = "duration:>1s"
+"payments errors" = { query = "service=payments level:error", key = "F2" }
+audit = "user -healthcheck"
+`
+	require.NoError(t, os.WriteFile(cfg.ConfigFile, []byte(content), 0o644))
+
+	// ignored by the flags parser
+	flags := map[string]string{}
+	require.NoError(t, configFileParser(strings.NewReader(content), func(name, value string) error {
+		flags[name] = value
+		return nil
+	}))
+	require.Equal(t, map[string]string{"nocolor": "true"}, flags)
+
+	require.NoError(t, loadConfigFile(cfg))
+	require.Equal(t, []SavedFilter{
+		{Name: "audit", Query: "user -healthcheck", Key: 1},
+		{Name: "payments errors", Query: "service=payments level:error", Key: 2},
+		{Name: "slow requests", Query: "duration:>1s", Key: 3},
+	}, cfg.Filters)
+
+	f, ok := savedFilterByKey(cfg.Filters, tcell.KeyF2)
+	require.True(t, ok)
+	require.Equal(t, "payments errors", f.Name)
+	_, ok = savedFilterByKey(cfg.Filters, tcell.KeyF4)
+	require.False(t, ok)
+
+	require.Equal(t, "slow requests", savedFilterName(cfg.Filters, "duration:>1s"))
+	require.Empty(t, savedFilterName(cfg.Filters, "duration:>1"))
+
+	for _, invalid := range []string{
+		`a = { key = "F1" }`,
+		`a = { query = "a", key = "F13" }`,
+		`a = { query = "a", key = "ctrl+a" }`,
+		"a = { query = \"a\", key = \"F1\" }\nb = { query = \"b\", key = \"f1\" }",
+		`a = 1`,
+	} {
+		require.NoError(t, os.WriteFile(cfg.ConfigFile, []byte("[filters]\n"+invalid), 0o644))
+		require.Error(t, loadConfigFile(cfg), invalid)
+	}
+}
+
+func TestFilterMenu(t *testing.T) {
+	filters := []SavedFilter{{Name: "a", Query: "qa"}, {Name: "b", Query: "qb"}}
+	c := NewFilterMenuComponent(&testPrinter{}, filters)
+
+	c.Open("qb")
+	require.True(t, c.IsOpen())
+	f, ok := c.Selected()
+	require.True(t, ok)
+	require.Equal(t, "b", f.Name)
+
+	c.CursorAdd(1)
+	f, _ = c.Selected()
+	require.Equal(t, "a", f.Name)
+
+	c.CursorAdd(1)
+	f, _ = c.Selected()
+	require.Equal(t, "a", f.Name)
+
+	c.Close()
+	require.False(t, c.IsOpen())
+}
+
+func TestFilterMenuRedraw(t *testing.T) {
+	printer := &testPrinter{}
+	c := NewFilterMenuComponent(printer, []SavedFilter{
+		{Name: "erreurs réseau", Query: "net", Key: 1},
+		{Name: "network errors", Query: "error"},
+	})
+
+	// queries are aligned on the display width of the names
+	c.Redraw(0, 0, 40, 3)
+	require.Equal(t, "F1   erreurs réseau net", strings.TrimRight(string(printer.lines[1]), " "))
+	require.Equal(t, "     network errors error", strings.TrimRight(string(printer.lines[2]), " "))
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"strings"
 	"sync"
+	"unicode/utf8"
 
 	"github.com/gdamore/tcell/v2"
 )
@@ -19,21 +20,75 @@ var simpleFilter = func(input string, value string) bool {
 }
 
 type InputComponent struct {
-	input *Input
+	input   *Input
+	filter  *LineFilter
+	bw      *BufferWindowLine
+	history *History
+	filters []SavedFilter
 
 	x, y    int
 	printer Printer
 }
 
-func NewInputComponent(lcfg *LoonConfig, p Printer, input *Input, xpos, ypos int) *InputComponent {
+func NewInputComponent(lcfg *LoonConfig, p Printer, input *Input, filter *LineFilter, bw *BufferWindowLine, history *History, xpos, ypos int) *InputComponent {
 	return &InputComponent{
 		input:   input,
+		filter:  filter,
+		bw:      bw,
+		history: history,
+		filters: lcfg.Filters,
 		printer: p,
 		x:       xpos, y: ypos,
 	}
 }
 
+func (i *InputComponent) tags() []string {
+	tags := []string{}
+	if name := savedFilterName(i.filters, i.input.Get()); name != "" {
+		tags = append(tags, name)
+	}
+
+	if mode := i.filter.Mode(); mode != FilterModeText {
+		tags = append(tags, mode.String())
+	}
+
+	if i.filter.Highlight() {
+		tags = append(tags, "highlight")
+	}
+
+	if mode := i.filter.CaseMode(); mode != CaseModeSmart {
+		tags = append(tags, mode.String())
+	}
+
+	if name := i.bw.SnapshotName(); name != "" {
+		tags = append(tags, name)
+	}
+
+	if i.bw.Expanded() {
+		tags = append(tags, "expanded")
+	}
+
+	if pattern, ok := i.history.Searching(); ok {
+		tags = append(tags, "search:"+pattern)
+	}
+
+	return tags
+}
+
 func (i *InputComponent) Redraw(x, y, width, height int) {
+	tagStyle := tcell.StyleDefault.Foreground(tcell.ColorDarkCyan).Bold(true)
+	for _, tag := range i.tags() {
+		x = i.printer.Print(x, y, tagStyle, "["+tag+"]")
+		x = i.printer.Print(x, y, tcell.StyleDefault, " ")
+	}
+
+	var status string
+	if err := i.filter.Err(); err != nil {
+		status = " " + err.Error()
+	} else if err := i.history.Err(); err != nil {
+		status = " " + err.Error()
+	}
+
 	style := tcell.StyleDefault
 	input := i.input.Get()
 	if input == "" {
@@ -42,11 +97,22 @@ func (i *InputComponent) Redraw(x, y, width, height int) {
 	}
 
 	var offset int
-	if size := len(input); size > width {
-		offset = size - width
+	if size := len(input) + len(status) + x; size > width {
+		if offset = size - width; offset > len(input) {
+			offset = len(input)
+		}
+
+		// do not split a rune
+		for offset < len(input) && !utf8.RuneStart(input[offset]) {
+			offset++
+		}
 	}
 
 	xoffset := i.printer.Print(x, y, style, input[offset:])
+	if status != "" {
+		xoffset = i.printer.Print(xoffset, y, tcell.StyleDefault.Foreground(tcell.ColorRed), status)
+	}
+
 	fillUpLine(i.printer, xoffset, y, width, tcell.StyleDefault)
 }
 
@@ -63,6 +129,12 @@ func (i *Input) Get() string {
 	return input
 }
 
+func (i *Input) Set(input string) {
+	i.muRunes.Lock()
+	i.runes = input
+	i.muRunes.Unlock()
+}
+
 func (i *Input) Add(r rune) {
 	i.muRunes.Lock()
 	i.runes += string(r)
@@ -71,8 +143,8 @@ func (i *Input) Add(r rune) {
 
 func (i *Input) DeleteBackward() {
 	i.muRunes.Lock()
-	if size := len(i.runes); size > 0 {
-		i.runes = i.runes[:size-1]
+	if _, size := utf8.DecodeLastRuneInString(i.runes); size > 0 {
+		i.runes = i.runes[:len(i.runes)-size]
 	}
 	i.muRunes.Unlock()
 }
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+	"github.com/peterbourgon/ff/v3/fftoml"
+)
+
+// configTables are tables of the config file which aren't flags, they are
+// loaded by `loadConfigFile`
+var configTables = []string{"format", "sources", "columns", "filters"}
+
+// configFileParser parse flags from the config file, ignoring config tables
+func configFileParser(r io.Reader, set func(name, value string) error) error {
+	return fftoml.Parser(r, func(name, value string) error {
+		for _, table := range configTables {
+			if strings.HasPrefix(name, table+".") {
+				return nil
+			}
+		}
+
+		return set(name, value)
+	})
+}
+
+// loadConfigFile load config tables from the config file, a missing config
+// file is not an error
+func loadConfigFile(cfg *LoonConfig) error {
+	tree, err := toml.LoadFile(cfg.ConfigFile)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil
+	case err != nil:
+		return fmt.Errorf("unable to load config file: %w", err)
+	}
+
+	cfg.Formats = map[string]*FormatConfig{}
+	if formats, ok := tree.Get("format").(*toml.Tree); ok {
+		for _, name := range formats.Keys() {
+			ftree, ok := formats.GetPath([]string{name}).(*toml.Tree)
+			if !ok {
+				return fmt.Errorf("format `%s` should be a table", name)
+			}
+
+			format, err := parseFormatConfig(name, ftree)
+			if err != nil {
+				return fmt.Errorf("invalid format `%s`: %w", name, err)
+			}
+
+			cfg.Formats[name] = format
+		}
+	}
+
+	cfg.Sources = map[string]string{}
+	if sources, ok := tree.Get("sources").(*toml.Tree); ok {
+		for _, glob := range sources.Keys() {
+			parser, ok := sources.GetPath([]string{glob}).(string)
+			if !ok {
+				return fmt.Errorf("parser of source `%s` should be a string", glob)
+			}
+
+			if _, err := filepath.Match(glob, ""); err != nil {
+				return fmt.Errorf("invalid source pattern `%s`: %w", glob, err)
+			}
+
+			cfg.Sources[glob] = parser
+		}
+	}
+
+	cfg.Columns = map[string][]string{}
+	if columns, ok := tree.Get("columns").(*toml.Tree); ok {
+		if cfg.Columns, err = parseColumns(columns); err != nil {
+			return err
+		}
+	}
+
+	cfg.Filters = nil
+	if filters, ok := tree.Get("filters").(*toml.Tree); ok {
+		if cfg.Filters, err = parseSavedFilters(filters); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SavedFilter is a named query of the config file, bound to a function key
+type SavedFilter struct {
+	Name, Query string
+
+	// Key is the number of the function key, 0 if unbound
+	Key int
+}
+
+// maximum number of function keys bound to saved filters
+const savedFilterKeys = 12
+
+// parseSavedFilters parse the named queries of the filters table, either
+// a query or a table with the query and its function key. Filters are
+// sorted by name, the ones without key are bound to the free function keys
+func parseSavedFilters(tree *toml.Tree) ([]SavedFilter, error) {
+	filters := []SavedFilter{}
+	bound := map[int]string{}
+	for _, name := range tree.Keys() {
+		filter := SavedFilter{Name: name}
+		switch value := tree.GetPath([]string{name}).(type) {
+		case string:
+			filter.Query = value
+		case *toml.Tree:
+			query, ok := value.Get("query").(string)
+			if !ok {
+				return nil, fmt.Errorf("filter `%s` should have a query", name)
+			}
+			filter.Query = query
+
+			if key, ok := value.Get("key").(string); ok {
+				n, err := parseFunctionKey(key)
+				if err != nil {
+					return nil, fmt.Errorf("invalid key of filter `%s`: %w", name, err)
+				}
+
+				if other, ok := bound[n]; ok {
+					return nil, fmt.Errorf("filters `%s` and `%s` are bound to %s", other, name, key)
+				}
+
+				filter.Key, bound[n] = n, name
+			}
+		default:
+			return nil, fmt.Errorf("filter `%s` should be a query or a table", name)
+		}
+
+		filters = append(filters, filter)
+	}
+
+	sort.Slice(filters, func(i, j int) bool {
+		return filters[i].Name < filters[j].Name
+	})
+
+	key := 1
+	for i := range filters {
+		if filters[i].Key > 0 {
+			continue
+		}
+
+		for bound[key] != "" {
+			key++
+		}
+
+		if key > savedFilterKeys {
+			break
+		}
+
+		filters[i].Key, bound[key] = key, filters[i].Name
+	}
+
+	return filters, nil
+}
+
+// parseFunctionKey parse a function key name from F1 to F12
+func parseFunctionKey(key string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(key), "F"))
+	if err != nil || !strings.HasPrefix(strings.ToUpper(key), "F") || n < 1 || n > savedFilterKeys {
+		return 0, fmt.Errorf("`%s` should be a function key from F1 to F%d", key, savedFilterKeys)
+	}
+
+	return n, nil
+}
+
+// parseColumns parse a table of sources patterns and their list of fields
+func parseColumns(tree *toml.Tree) (map[string][]string, error) {
+	columns := map[string][]string{}
+	for _, glob := range tree.Keys() {
+		values, ok := tree.GetPath([]string{glob}).([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("columns of source `%s` should be a list of fields", glob)
+		}
+
+		fields := make([]string, len(values))
+		for i, value := range values {
+			if fields[i], ok = value.(string); !ok {
+				return nil, fmt.Errorf("columns of source `%s` should be a list of fields", glob)
+			}
+		}
+
+		if _, err := filepath.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid source pattern `%s`: %w", glob, err)
+		}
+
+		columns[glob] = fields
+	}
+
+	return columns, nil
+}
+
+// loadColumnsFile load the columns chosen with the picker, a missing file
+// is not an error
+func loadColumnsFile(cfg *LoonConfig) error {
+	cfg.SavedColumns = map[string][]string{}
+	if cfg.ColumnsFile == "" {
+		return nil
+	}
+
+	tree, err := toml.LoadFile(cfg.ColumnsFile)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil
+	case err != nil:
+		return fmt.Errorf("unable to load columns file: %w", err)
+	}
+
+	if cfg.SavedColumns, err = parseColumns(tree); err != nil {
+		return fmt.Errorf("invalid columns file: %w", err)
+	}
+
+	return nil
+}
+
+// sourceColumns return the fields displayed for the given source, nil if
+// all fields are displayed. Columns chosen with the picker take precedence
+// over the config file
+func sourceColumns(cfg *LoonConfig, f File) []string {
+	if glob := sourcePattern(cfg.SavedColumns, f); glob != "" {
+		if columns := cfg.SavedColumns[glob]; len(columns) > 0 {
+			return columns
+		}
+		return nil
+	}
+
+	if glob := sourcePattern(cfg.Columns, f); glob != "" {
+		return cfg.Columns[glob]
+	}
+
+	return nil
+}
+
+// saveColumnsFile save the fields displayed for the given source in the
+// columns file, under its matching pattern or its base name. No columns
+// display all fields, even when the config file set columns for the source
+func saveColumnsFile(cfg *LoonConfig, f File, columns []string) error {
+	if cfg.ColumnsFile == "" {
+		return fmt.Errorf("no columns file to save columns")
+	}
+
+	glob := sourcePattern(cfg.SavedColumns, f)
+	if glob == "" {
+		glob = sourcePattern(cfg.Columns, f)
+	}
+	if glob == "" {
+		glob = filepath.Base(f.Path)
+	}
+
+	saved := map[string][]string{}
+	for key, value := range cfg.SavedColumns {
+		saved[key] = value
+	}
+
+	_, configured := cfg.Columns[glob]
+	switch {
+	case len(columns) > 0:
+		saved[glob] = columns
+	case configured:
+		saved[glob] = []string{}
+	default:
+		delete(saved, glob)
+	}
+
+	values := map[string]interface{}{}
+	for key, fields := range saved {
+		list := make([]interface{}, len(fields))
+		for i, field := range fields {
+			list[i] = field
+		}
+		values[key] = list
+	}
+
+	tree, err := toml.TreeFromMap(values)
+	if err != nil {
+		return fmt.Errorf("unable to encode columns: %w", err)
+	}
+
+	content, err := tree.ToTomlString()
+	if err != nil {
+		return fmt.Errorf("unable to encode columns: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cfg.ColumnsFile), 0o755); err != nil {
+		return fmt.Errorf("unable to create columns directory: %w", err)
+	}
+
+	if err := os.WriteFile(cfg.ColumnsFile, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("unable to write columns file: %w", err)
+	}
+
+	cfg.SavedColumns = saved
+	return nil
+}
@@ -0,0 +1,487 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+type FilterMode int
+
+const (
+	FilterModeText FilterMode = iota
+	FilterModeRegex
+	FilterModeFuzzy
+
+	filterModeCount
+)
+
+func (m FilterMode) String() string {
+	switch m {
+	case FilterModeText:
+		return "text"
+	case FilterModeRegex:
+		return "regex"
+	case FilterModeFuzzy:
+		return "fuzzy"
+	default:
+		return "unknown"
+	}
+}
+
+type CaseMode int
+
+const (
+	// CaseModeSmart is case-insensitive unless the term contains an uppercase
+	// letter
+	CaseModeSmart CaseMode = iota
+	CaseModeSensitive
+	CaseModeInsensitive
+
+	caseModeCount
+)
+
+func (m CaseMode) String() string {
+	switch m {
+	case CaseModeSmart:
+		return "smartcase"
+	case CaseModeSensitive:
+		return "case"
+	case CaseModeInsensitive:
+		return "nocase"
+	default:
+		return "unknown"
+	}
+}
+
+// ignoreCase report if the given pattern should be matched
+// case-insensitively, escaped sequences of regex patterns such as `\S` are
+// not considered as uppercase letters
+func (m CaseMode) ignoreCase(pattern string, regex bool) bool {
+	switch m {
+	case CaseModeSensitive:
+		return false
+	case CaseModeInsensitive:
+		return true
+	}
+
+	for i := 0; i < len(pattern); {
+		r, size := utf8.DecodeRuneInString(pattern[i:])
+		if regex && r == '\\' {
+			_, esize := utf8.DecodeRuneInString(pattern[i+size:])
+			size += esize
+		} else if unicode.IsUpper(r) {
+			return false
+		}
+		i += size
+	}
+
+	return true
+}
+
+// matcher return marks found on the given line, a nil matcher match
+// everything
+type matcher func(l Line, str string) (marks []Mark, ok bool)
+
+// LineFilter hold the compiled version of the input, it should be updated on
+// each input edit
+type LineFilter struct {
+	muFilter sync.RWMutex
+
+	mode     FilterMode
+	caseMode CaseMode
+	input    string
+	matcher  matcher
+	err      error
+
+	// highlight match every line not excluded by a negated term, the
+	// other terms are only marked
+	highlight bool
+}
+
+func NewLineFilter() *LineFilter {
+	return &LineFilter{mode: FilterModeText, caseMode: CaseModeSmart}
+}
+
+func (f *LineFilter) Mode() (mode FilterMode) {
+	f.muFilter.RLock()
+	mode = f.mode
+	f.muFilter.RUnlock()
+	return
+}
+
+func (f *LineFilter) CaseMode() (mode CaseMode) {
+	f.muFilter.RLock()
+	mode = f.caseMode
+	f.muFilter.RUnlock()
+	return
+}
+
+// NextCaseMode switch to the next case mode and recompile the current input
+func (f *LineFilter) NextCaseMode() {
+	f.muFilter.Lock()
+	f.caseMode = (f.caseMode + 1) % caseModeCount
+	f.compile(f.input)
+	f.muFilter.Unlock()
+}
+
+func (f *LineFilter) Highlight() (highlight bool) {
+	f.muFilter.RLock()
+	highlight = f.highlight
+	f.muFilter.RUnlock()
+	return
+}
+
+// ToggleHighlight switch between filtering and highlighting lines, and
+// recompile the current input
+func (f *LineFilter) ToggleHighlight() {
+	f.muFilter.Lock()
+	f.highlight = !f.highlight
+	f.compile(f.input)
+	f.muFilter.Unlock()
+}
+
+// Err return the last compile error if any
+func (f *LineFilter) Err() (err error) {
+	f.muFilter.RLock()
+	err = f.err
+	f.muFilter.RUnlock()
+	return
+}
+
+// NextMode switch to the next filter mode and recompile the current input
+func (f *LineFilter) NextMode() {
+	f.muFilter.Lock()
+	f.mode = (f.mode + 1) % filterModeCount
+	f.compile(f.input)
+	f.muFilter.Unlock()
+}
+
+// Update compile the given input, on error the previous matcher is kept
+func (f *LineFilter) Update(input string) {
+	f.muFilter.Lock()
+	f.compile(input)
+	f.muFilter.Unlock()
+}
+
+func (f *LineFilter) compile(input string) {
+	f.input = input
+
+	caseMode, highlight := f.caseMode, f.highlight
+
+	var m matcher
+	var err error
+	switch f.mode {
+	case FilterModeRegex:
+		m, err = compileQueryMatcher(input, highlight, func(term *queryTerm) error {
+			return compileRegexTerm(term, caseMode)
+		})
+	case FilterModeFuzzy:
+		m, err = compileQueryMatcher(input, highlight, func(term *queryTerm) error {
+			return compileFuzzyTerm(term, caseMode)
+		})
+	default:
+		m, err = compileQueryMatcher(input, highlight, func(term *queryTerm) error {
+			return compileTextTerm(term, caseMode)
+		})
+	}
+
+	if f.err = err; err == nil {
+		f.matcher = m
+	}
+}
+
+func (f *LineFilter) Match(l Line) bool {
+	f.muFilter.RLock()
+	m := f.matcher
+	f.muFilter.RUnlock()
+
+	if m == nil {
+		l.SetMarks()
+		return true
+	}
+
+	marks, ok := m(l, l.String())
+	l.SetMarks(marks...)
+	return ok
+}
+
+// Score return the score of the given line, higher is better
+func (f *LineFilter) Score(l Line) (score int, ok bool) {
+	f.muFilter.RLock()
+	m := f.matcher
+	f.muFilter.RUnlock()
+
+	if m == nil {
+		return 0, true
+	}
+
+	line := l.String()
+	marks, ok := m(l, line)
+	return fuzzyScore(line, marks), ok
+}
+
+// compileQueryMatcher compile the given query, in highlight mode every line
+// not excluded by the query match
+func compileQueryMatcher(input string, highlight bool, compileTerm func(term *queryTerm) error) (matcher, error) {
+	node, terms, err := parseQuery(input)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+
+	if node == nil {
+		return nil, nil
+	}
+
+	for _, term := range terms {
+		if term.op != "" {
+			if err := compileCompareTerm(term); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if err := compileTerm(term); err != nil {
+			return nil, err
+		}
+
+		if term.field == "" {
+			continue
+		}
+
+		fallback := &queryTerm{n: term.n, value: term.raw}
+		if err := compileTerm(fallback); err != nil {
+			return nil, err
+		}
+		term.fallback = fallback.match
+	}
+
+	if highlight {
+		return highlightMatcher(node), nil
+	}
+
+	return node.eval, nil
+}
+
+// highlightMatcher match lines which aren't excluded by the negated terms
+// of the query, and mark all the other terms found on them
+func highlightMatcher(node queryNode) matcher {
+	var exclusions []queryNode
+	switch n := node.(type) {
+	case *queryNot:
+		exclusions = append(exclusions, n)
+	case queryAnd:
+		for _, op := range n {
+			if _, ok := op.(*queryNot); ok {
+				exclusions = append(exclusions, op)
+			}
+		}
+	}
+
+	terms := queryPositiveTerms(node)
+	return func(l Line, str string) ([]Mark, bool) {
+		for _, exclusion := range exclusions {
+			if _, ok := exclusion.eval(l, str); !ok {
+				return nil, false
+			}
+		}
+
+		marks := []Mark{}
+		for _, term := range terms {
+			if m, ok := term.eval(l, str); ok {
+				marks = append(marks, m...)
+			}
+		}
+
+		return marks, true
+	}
+}
+
+type compareKind int
+
+const (
+	compareNumber compareKind = iota
+	compareDuration
+	compareLevel
+)
+
+// parseComparable parse the given value as a number, a duration or a level
+func parseComparable(value string) (float64, compareKind, bool) {
+	value = strings.TrimSpace(value)
+	if n, err := strconv.ParseFloat(value, 64); err == nil {
+		return n, compareNumber, true
+	}
+
+	if d, err := time.ParseDuration(value); err == nil {
+		return float64(d), compareDuration, true
+	}
+
+	if level, ok := ParseLevel(value); ok {
+		return float64(level), compareLevel, true
+	}
+
+	return 0, 0, false
+}
+
+func compileCompareTerm(term *queryTerm) error {
+	threshold, kind, ok := parseComparable(term.value)
+	if !ok {
+		return fmt.Errorf("invalid comparison value `%s`", term.value)
+	}
+
+	var cmp func(a, b float64) bool
+	switch term.op {
+	case ">":
+		cmp = func(a, b float64) bool { return a > b }
+	case ">=":
+		cmp = func(a, b float64) bool { return a >= b }
+	case "<":
+		cmp = func(a, b float64) bool { return a < b }
+	case "<=":
+		cmp = func(a, b float64) bool { return a <= b }
+	default:
+		return fmt.Errorf("invalid comparison operator `%s`", term.op)
+	}
+
+	term.compare = func(value string) bool {
+		n, k, ok := parseComparable(value)
+		return ok && k == kind && cmp(n, threshold)
+	}
+
+	return nil
+}
+
+func compileTextTerm(term *queryTerm, caseMode CaseMode) error {
+	in := term.value
+
+	index := func(s string) (int, int) {
+		if i := strings.Index(s, in); i >= 0 {
+			return i, i + len(in)
+		}
+		return -1, -1
+	}
+
+	if caseMode.ignoreCase(in, false) {
+		index = func(s string) (int, int) {
+			return indexFold(s, in)
+		}
+	}
+
+	term.match = func(line string) []Mark {
+		marks := []Mark{}
+		for i := 0; i < len(line); {
+			start, end := index(line[i:])
+			if start < 0 {
+				break
+			}
+
+			marks = append(marks, Mark{N: term.n, Off: start + i, Len: end - start})
+			i += end
+		}
+
+		return marks
+	}
+
+	return nil
+}
+
+// indexFold return the byte range of the first instance of substr in s
+// under simple Unicode case folding, or -1 if substr is not present. Since
+// folding can change the byte length of a rune (`K` and the kelvin sign
+// `\u212A`), the returned range is always relative to s.
+func indexFold(s, substr string) (start, end int) {
+	if substr == "" {
+		return -1, -1
+	}
+
+	for start = 0; start < len(s); {
+		if end = prefixFold(s[start:], substr); end > 0 {
+			return start, start + end
+		}
+
+		_, size := utf8.DecodeRuneInString(s[start:])
+		start += size
+	}
+
+	return -1, -1
+}
+
+// prefixFold return the length in s of the prefix matching substr under
+// case folding, or -1
+func prefixFold(s, substr string) int {
+	var i int
+	for _, sr := range substr {
+		if i >= len(s) {
+			return -1
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if !equalFoldRune(r, sr) {
+			return -1
+		}
+		i += size
+	}
+
+	return i
+}
+
+func equalFoldRune(a, b rune) bool {
+	if a == b {
+		return true
+	}
+
+	for r := unicode.SimpleFold(a); r != a; r = unicode.SimpleFold(r) {
+		if r == b {
+			return true
+		}
+	}
+
+	return false
+}
+
+func compileRegexTerm(term *queryTerm, caseMode CaseMode) error {
+	pattern := term.value
+	if caseMode.ignoreCase(pattern, true) {
+		pattern = "(?i)" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	term.match = func(line string) []Mark {
+		return regexMarks(re.FindAllStringSubmatchIndex(line, -1), term.n)
+	}
+
+	return nil
+}
+
+// regexMarks convert submatch indexes into marks, if the pattern has
+// capture groups only the groups are marked
+func regexMarks(matches [][]int, n int) []Mark {
+	marks := []Mark{}
+	for _, match := range matches {
+		if len(match) == 2 {
+			if match[1] > match[0] {
+				marks = append(marks, Mark{N: n, Off: match[0], Len: match[1] - match[0]})
+			}
+			continue
+		}
+
+		for g := 2; g+1 < len(match); g += 2 {
+			if match[g] < 0 || match[g+1] <= match[g] {
+				continue
+			}
+
+			marks = append(marks, Mark{N: n, Off: match[g], Len: match[g+1] - match[g]})
+		}
+	}
+
+	return marks
+}
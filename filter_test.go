@@ -0,0 +1,175 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testFilterCase struct {
+	Name, Input, Line string
+	Match             bool
+	Marks             []Mark
+}
+
+func testFilterCases(t *testing.T, f *LineFilter, cases []testFilterCase) {
+	t.Helper()
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			f.Update(tc.Input)
+			require.NoError(t, f.Err())
+
+			line := ParseANSILine(tc.Line, false)
+			require.Equal(t, tc.Match, f.Match(line))
+			if tc.Marks == nil {
+				require.Empty(t, line.marks)
+			} else {
+				require.Equal(t, tc.Marks, line.marks)
+			}
+		})
+	}
+}
+
+func testLineMarks(l Line) []Mark {
+	switch line := l.(type) {
+	case *ANSILine:
+		return line.marks
+	case *StructuredLine:
+		return line.marks
+	default:
+		return nil
+	}
+}
+
+func TestFilterText(t *testing.T) {
+	f := NewLineFilter()
+	testFilterCases(t, f, []testFilterCase{
+		{"empty input", "", "foo bar", true, nil},
+		{"single term", "bar", "foo bar bar", true, []Mark{{0, 4, 3}, {0, 8, 3}}},
+		{"multiple terms", "foo bar", "foo bar", true, []Mark{{0, 0, 3}, {1, 4, 3}}},
+		{"trailing space", "foo ", "foo", true, []Mark{{0, 0, 3}}},
+		{"no match", "baz", "foo bar", false, nil},
+	})
+}
+
+func TestFilterRegex(t *testing.T) {
+	f := NewLineFilter()
+	f.NextMode()
+	require.Equal(t, FilterModeRegex, f.Mode())
+
+	testFilterCases(t, f, []testFilterCase{
+		{"empty input", "", "foo bar", true, nil},
+		{"full match", `status=5\d\d`, "a status=503 b", true, []Mark{{0, 2, 10}}},
+		{"anchored", `^foo`, "foo foo", true, []Mark{{0, 0, 3}}},
+		{"anchored no match", `^bar`, "foo bar", false, nil},
+		{"submatch", `(\w+)=(\d+)`, "a=1 b=22", true, []Mark{
+			{0, 0, 1}, {0, 2, 1}, {0, 4, 1}, {0, 6, 2},
+		}},
+		{"multiple patterns", `^foo \d+$`, "foo 42", true, []Mark{{0, 0, 3}, {1, 4, 2}}},
+		{"quoted pattern", `"^foo \d+$"`, "foo 42", true, []Mark{{0, 0, 6}}},
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		f.Update("foo")
+		f.Update("foo(")
+		require.Error(t, f.Err())
+
+		// previous matcher should be kept
+		line := ParseANSILine("foo", false)
+		require.True(t, f.Match(line))
+		require.False(t, f.Match(ParseANSILine("bar", false)))
+	})
+
+	t.Run("nested groups score", func(t *testing.T) {
+		f.Update("((a)b)")
+		require.NoError(t, f.Err())
+
+		score, ok := f.Score(ParseANSILine("xab", false))
+		require.True(t, ok)
+		require.Greater(t, score, 0)
+	})
+}
+
+func TestFilterCase(t *testing.T) {
+	f := NewLineFilter()
+	require.Equal(t, CaseModeSmart, f.CaseMode())
+
+	testFilterCases(t, f, []testFilterCase{
+		{"smartcase lower", "error", "ERROR Error", true, []Mark{{0, 0, 5}, {0, 6, 5}}},
+		{"smartcase upper", "Error", "ERROR Error", true, []Mark{{0, 6, 5}}},
+		{"smartcase keyword", "error AND payment", "ERROR Payment", true, []Mark{{0, 0, 5}, {1, 6, 7}}},
+		{"fold length", "k", "Ka k", true, []Mark{{0, 0, 3}, {0, 5, 1}}},
+		{"fold length offset", "ſa", "xSA", true, []Mark{{0, 1, 2}}},
+	})
+
+	f.NextCaseMode()
+	require.Equal(t, CaseModeSensitive, f.CaseMode())
+	testFilterCases(t, f, []testFilterCase{
+		{"sensitive", "error", "ERROR error", true, []Mark{{0, 6, 5}}},
+	})
+
+	f.NextCaseMode()
+	require.Equal(t, CaseModeInsensitive, f.CaseMode())
+	testFilterCases(t, f, []testFilterCase{
+		{"insensitive", "Error", "ERROR error", true, []Mark{{0, 0, 5}, {0, 6, 5}}},
+	})
+
+	f.NextCaseMode()
+	f.NextMode()
+	require.Equal(t, FilterModeRegex, f.Mode())
+	testFilterCases(t, f, []testFilterCase{
+		{"regex smartcase", `err\w+`, "ERROR", true, []Mark{{0, 0, 5}}},
+		{"regex escape", `\Serror`, "xERROR", true, []Mark{{0, 0, 6}}},
+		{"regex smartcase upper", `Err\w+`, "ERROR", false, nil},
+	})
+}
+
+func TestFilterFuzzy(t *testing.T) {
+	f := NewLineFilter()
+	f.NextMode()
+	f.NextMode()
+	require.Equal(t, FilterModeFuzzy, f.Mode())
+
+	testFilterCases(t, f, []testFilterCase{
+		{"subsequence", "cnrst", "connection reset", true, []Mark{{0, 5, 1}, {0, 9, 1}, {0, 11, 1}, {0, 13, 1}, {0, 15, 1}}},
+		{"shortest match", "abc", "a abc", true, []Mark{{0, 2, 3}}},
+		{"no match", "abc", "acb", false, nil},
+		{"smartcase", "ERR", "an err", false, nil},
+		{"multiple terms", "cr AND db", "db connection reset", true, []Mark{{0, 8, 1}, {0, 14, 1}, {1, 0, 2}}},
+	})
+
+	t.Run("score", func(t *testing.T) {
+		f.Update("err")
+		better, ok := f.Score(ParseANSILine("an error", false))
+		require.True(t, ok)
+		worse, ok := f.Score(ParseANSILine("e or rare", false))
+		require.True(t, ok)
+		require.Greater(t, better, worse)
+
+		_, ok = f.Score(ParseANSILine("nothing", false))
+		require.False(t, ok)
+	})
+}
+
+func TestFilterHighlight(t *testing.T) {
+	f := NewLineFilter()
+	f.ToggleHighlight()
+	require.True(t, f.Highlight())
+
+	testFilterCases(t, f, []testFilterCase{
+		{"empty input", "", "foo bar", true, nil},
+		{"no match", "baz", "foo bar", true, []Mark{}},
+		{"single term", "bar", "foo bar", true, []Mark{{0, 4, 3}}},
+		{"and", "foo AND baz", "foo bar", true, []Mark{{0, 0, 3}}},
+		{"exclusion", "foo -healthcheck", "GET /healthcheck foo", false, nil},
+		{"not excluded", "foo -healthcheck", "GET /api", true, []Mark{}},
+		{"only exclusion", "NOT healthcheck", "GET /api", true, []Mark{}},
+		{"negated terms aren't marked", "bar OR NOT foo", "foo bar", true, []Mark{{0, 4, 3}}},
+	})
+
+	f.ToggleHighlight()
+	testFilterCases(t, f, []testFilterCase{
+		{"filter again", "baz", "foo bar", false, nil},
+	})
+}
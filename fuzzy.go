@@ -0,0 +1,188 @@
+package main
+
+import (
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+// fuzzy scoring, loosely based on fzf
+const (
+	fuzzyScoreMatch        = 16
+	fuzzyScoreGapStart     = -3
+	fuzzyScoreGapExtension = -1
+
+	fuzzyBonusBoundary    = fuzzyScoreMatch / 2
+	fuzzyBonusCamel       = fuzzyBonusBoundary - 1
+	fuzzyBonusConsecutive = -(fuzzyScoreGapStart + fuzzyScoreGapExtension)
+	fuzzyBonusFirstChar   = 2
+)
+
+func compileFuzzyTerm(term *queryTerm, caseMode CaseMode) error {
+	pattern := []rune(term.value)
+	fold := caseMode.ignoreCase(term.value, false)
+
+	term.match = func(line string) []Mark {
+		return fuzzyMarks(term.n, line, fuzzyMatch(line, pattern, fold))
+	}
+
+	return nil
+}
+
+// fuzzyMatch return the byte offsets of the runes of line matching the
+// pattern, or nil if the line doesn't contain the pattern as a subsequence.
+// The first occurrence is found with a forward scan, then a backward scan
+// from its end shrink it to the shortest possible match.
+func fuzzyMatch(line string, pattern []rune, fold bool) []int {
+	if len(pattern) == 0 {
+		return nil
+	}
+
+	equal := func(a, b rune) bool {
+		if fold {
+			return equalFoldRune(a, b)
+		}
+		return a == b
+	}
+
+	// forward scan
+	pidx, end := 0, -1
+	for i, r := range line {
+		if equal(r, pattern[pidx]) {
+			if pidx++; pidx == len(pattern) {
+				end = i + utf8.RuneLen(r)
+				break
+			}
+		}
+	}
+
+	if end < 0 {
+		return nil
+	}
+
+	// backward scan
+	positions := make([]int, len(pattern))
+	pidx = len(pattern) - 1
+	for i := end; i > 0 && pidx >= 0; {
+		r, size := utf8.DecodeLastRuneInString(line[:i])
+		i -= size
+
+		if equal(r, pattern[pidx]) {
+			positions[pidx] = i
+			pidx--
+		}
+	}
+
+	return positions
+}
+
+// fuzzyMarks convert matched positions into marks, consecutive runes are
+// merged into a single mark
+func fuzzyMarks(n int, line string, positions []int) []Mark {
+	marks := []Mark{}
+	for _, pos := range positions {
+		_, size := utf8.DecodeRuneInString(line[pos:])
+		if last := len(marks) - 1; last >= 0 && marks[last].Off+marks[last].Len == pos {
+			marks[last].Len += size
+			continue
+		}
+
+		marks = append(marks, Mark{N: n, Off: pos, Len: size})
+	}
+
+	return marks
+}
+
+type fuzzyCharClass int
+
+const (
+	fuzzyCharNone fuzzyCharClass = iota
+	fuzzyCharLower
+	fuzzyCharUpper
+	fuzzyCharNumber
+)
+
+func fuzzyClassOf(r rune) fuzzyCharClass {
+	switch {
+	case unicode.IsLower(r):
+		return fuzzyCharLower
+	case unicode.IsUpper(r):
+		return fuzzyCharUpper
+	case unicode.IsNumber(r):
+		return fuzzyCharNumber
+	default:
+		return fuzzyCharNone
+	}
+}
+
+func fuzzyBonus(prev, cur fuzzyCharClass) int {
+	switch {
+	case prev == fuzzyCharNone && cur != fuzzyCharNone:
+		return fuzzyBonusBoundary
+	case prev == fuzzyCharLower && cur == fuzzyCharUpper,
+		prev != fuzzyCharNumber && cur == fuzzyCharNumber:
+		return fuzzyBonusCamel
+	default:
+		return 0
+	}
+}
+
+// fuzzyScore score the given marks on line, matched runes are rewarded,
+// specially on word boundaries and when consecutive, and gaps between them
+// are penalized. Marks of each term are scored separately, in order of
+// offset, overlapping runes are scored once.
+func fuzzyScore(line string, marks []Mark) (score int) {
+	type termState struct {
+		last  int // end offset of the last matched rune
+		first bool
+	}
+
+	sorted := make([]Mark, len(marks))
+	copy(sorted, marks)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Off < sorted[j].Off
+	})
+
+	terms := map[int]*termState{}
+	for _, m := range sorted {
+		state, ok := terms[m.N]
+		if !ok {
+			state = &termState{last: -1, first: true}
+			terms[m.N] = state
+		}
+
+		off := m.Off
+		if off < state.last {
+			off = state.last
+		}
+
+		for off < m.Off+m.Len && off < len(line) {
+			r, size := utf8.DecodeRuneInString(line[off:])
+
+			prev := fuzzyCharNone
+			if off > 0 {
+				pr, _ := utf8.DecodeLastRuneInString(line[:off])
+				prev = fuzzyClassOf(pr)
+			}
+
+			bonus := fuzzyBonus(prev, fuzzyClassOf(r))
+			switch {
+			case state.first:
+				bonus *= fuzzyBonusFirstChar
+			case state.last == off:
+				if bonus < fuzzyBonusConsecutive {
+					bonus = fuzzyBonusConsecutive
+				}
+			default:
+				gap := utf8.RuneCountInString(line[state.last:off])
+				score += fuzzyScoreGapStart + fuzzyScoreGapExtension*(gap-1)
+			}
+
+			score += fuzzyScoreMatch + bonus
+			state.first, state.last = false, off+size
+			off += size
+		}
+	}
+
+	return score
+}
@@ -8,6 +8,7 @@ require (
 	github.com/mattn/go-runewidth v0.0.13
 	github.com/nxadm/tail v1.4.8
 	github.com/oklog/run v1.1.0
+	github.com/pelletier/go-toml v1.6.0
 	github.com/peterbourgon/ff/v3 v3.1.2
 	github.com/stretchr/testify v1.7.2
 	github.com/teacat/noire v1.1.0
@@ -18,7 +19,6 @@ require (
 	github.com/fsnotify/fsnotify v1.4.9 // indirect
 	github.com/gdamore/encoding v1.0.0 // indirect
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
-	github.com/pelletier/go-toml v1.6.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/rivo/uniseg v0.2.0 // indirect
 	golang.org/x/sys v0.0.0-20220318055525-2edf467146b5 // indirect
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// `goroutine 42 [chan receive, 5 minutes]:`, go 1.23 tracebacks may add
+// details such as `gp=0xc000006380 m=0` before the state
+var goroutineHeaderRegexp = regexp.MustCompile(`^goroutine (\d+)(?: [^\[]*)? \[([^\],]+)(?:, ([^\]]+))?\]:$`)
+
+// Goroutine is a goroutine block of a go stack dump
+type Goroutine struct {
+	ID    string
+	State string
+
+	// Func is the top frame function, frames of the runtime package are
+	// skipped when possible
+	Func string
+
+	// offsets in the record content
+	idOff, stateOff, funcOff int
+}
+
+func isGoroutineHeader(s string) bool {
+	return strings.HasPrefix(s, "goroutine ") && goroutineHeaderRegexp.MatchString(s)
+}
+
+// parseGoroutine parse a goroutine block, offs being the offset of each line
+// in the record content
+func parseGoroutine(lines []Line, offs []int) (*Goroutine, bool) {
+	header := lines[0].String()
+	match := goroutineHeaderRegexp.FindStringSubmatchIndex(header)
+	if match == nil {
+		return nil, false
+	}
+
+	g := &Goroutine{
+		ID:       header[match[2]:match[3]],
+		State:    header[match[4]:match[5]],
+		idOff:    match[2],
+		stateOff: match[4],
+		funcOff:  -1,
+	}
+
+	// frames are a function call followed by its indented location
+	for i := 1; i < len(lines); i++ {
+		frame := lines[i].String()
+		if frame == "" || frame[0] == '\t' || frame[0] == ' ' || strings.HasPrefix(frame, "created by ") {
+			continue
+		}
+
+		name := frame
+		if n := strings.LastIndexByte(frame, '('); n > 0 && strings.HasSuffix(frame, ")") {
+			name = frame[:n]
+		}
+
+		if g.Func == "" || strings.HasPrefix(g.Func, "runtime.") {
+			g.Func, g.funcOff = name, offs[i]
+		}
+
+		if !strings.HasPrefix(name, "runtime.") {
+			break
+		}
+	}
+
+	return g, true
+}
+
+// Field expose the goroutine `id`, `state` and top frame `func`
+func (g *Goroutine) Field(key string) (string, int, bool) {
+	switch strings.ToLower(key) {
+	case "goroutine", "id":
+		return g.ID, g.idOff, true
+	case "state":
+		return g.State, g.stateOff, true
+	case "func":
+		return g.Func, g.funcOff, g.Func != ""
+	default:
+		return "", -1, false
+	}
+}
+
+// withGoroutines fold goroutine blocks up to the next empty line, other
+// lines use the given rule
+func (rule MultilineRule) withGoroutines() MultilineRule {
+	return func(record []Line, l Line) bool {
+		s := l.String()
+		switch {
+		case isGoroutineHeader(s):
+			return false
+		case isGoroutineHeader(record[0].String()):
+			return s != ""
+		case rule == nil:
+			return false
+		default:
+			return rule(record, l)
+		}
+	}
+}
+
+// GoroutineSummary count goroutines by state and top frame, the most
+// common first
+func GoroutineSummary(records []*Record) []Line {
+	type group struct {
+		state, fn string
+		count     int
+		sid       SourceID
+	}
+
+	groups := map[[2]string]*group{}
+	for _, r := range records {
+		g, ok := r.Goroutine()
+		if !ok {
+			continue
+		}
+
+		key := [2]string{g.State, g.Func}
+		if _, ok := groups[key]; !ok {
+			groups[key] = &group{state: g.State, fn: g.Func, sid: r.Source()}
+		}
+		groups[key].count++
+	}
+
+	sorted := make([]*group, 0, len(groups))
+	var total, stateWidth int
+	for _, g := range groups {
+		sorted = append(sorted, g)
+		total += g.count
+		if len(g.state) > stateWidth {
+			stateWidth = len(g.state)
+		}
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].count != sorted[j].count {
+			return sorted[i].count > sorted[j].count
+		}
+		return sorted[i].state+sorted[i].fn < sorted[j].state+sorted[j].fn
+	})
+
+	if len(sorted) == 0 {
+		return nil
+	}
+
+	countWidth := len(strconv.Itoa(total))
+	lines := make([]Line, 0, len(sorted)+1)
+
+	header := ParseANSILine(fmt.Sprintf("%d goroutines, %d groups", total, len(sorted)), false)
+	header.sid = sorted[0].sid
+	lines = append(lines, header)
+
+	for _, g := range sorted {
+		l := ParseANSILine(fmt.Sprintf("%*d  %-*s  %s", countWidth, g.count, stateWidth, g.state, g.fn), false)
+		l.sid = g.sid
+		lines = append(lines, l)
+	}
+
+	return lines
+}
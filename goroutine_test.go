@@ -0,0 +1,117 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testGoroutineDump = []string{
+	"panic: boom",
+	"",
+	"goroutine 1 [running]:",
+	"main.crash(...)",
+	"\t/src/main.go:12",
+	"main.main()",
+	"\t/src/main.go:8 +0x1d",
+	"",
+	"goroutine 18 [chan receive, 5 minutes]:",
+	"runtime.gopark(0x0?, 0x0?, 0x0?, 0x0?, 0x0?)",
+	"\t/usr/local/go/src/runtime/proc.go:398 +0xce",
+	"runtime.chanrecv(0xc000022060, 0x0, 0x1)",
+	"\t/usr/local/go/src/runtime/chan.go:583 +0x3cd",
+	"main.(*pool).worker(0xc000010000)",
+	"\t/src/pool.go:42 +0x45",
+	"created by main.newPool in goroutine 1",
+	"\t/src/pool.go:20 +0x85",
+	"",
+	"goroutine 19 gp=0xc000006380 m=nil [chan receive]:",
+	"runtime.gopark(0x0?, 0x0?, 0x0?, 0x0?, 0x0?)",
+	"\t/usr/local/go/src/runtime/proc.go:398 +0xce",
+	"main.(*pool).worker(0xc000010000)",
+	"\t/src/pool.go:42 +0x45",
+	"exit status 2",
+}
+
+func testGoroutineRecords(t *testing.T) []*Record {
+	t.Helper()
+
+	var rule MultilineRule
+	records := []*Record{}
+	for _, entry := range testFoldLines(t, rule.withGoroutines(), testGoroutineDump...) {
+		if len(entry) == 1 {
+			continue
+		}
+
+		record := NewRecord(ParseANSILine(entry[0], false))
+		for _, l := range entry[1:] {
+			record.Append(ParseANSILine(l, false))
+		}
+		records = append(records, record)
+	}
+
+	return records
+}
+
+func TestGoroutineFold(t *testing.T) {
+	var rule MultilineRule
+	entries := testFoldLines(t, rule.withGoroutines(), testGoroutineDump...)
+	require.Len(t, entries, 7)
+	require.Equal(t, []string{"panic: boom"}, entries[0])
+	require.Len(t, entries[2], 5)
+	require.Len(t, entries[4], 9)
+	require.Equal(t, "exit status 2", entries[6][len(entries[6])-1])
+}
+
+func TestGoroutineFields(t *testing.T) {
+	records := testGoroutineRecords(t)
+	require.Len(t, records, 3)
+
+	cases := []struct {
+		ID, State, Func string
+	}{
+		{"1", "running", "main.crash"},
+		{"18", "chan receive", "main.(*pool).worker"},
+		{"19", "chan receive", "main.(*pool).worker"},
+	}
+
+	for i, tc := range cases {
+		g, ok := records[i].Goroutine()
+		require.True(t, ok)
+		require.Equal(t, tc.ID, g.ID)
+		require.Equal(t, tc.State, g.State)
+		require.Equal(t, tc.Func, g.Func)
+
+		for _, field := range []string{"id", "state", "func"} {
+			value, off, ok := records[i].Field(field)
+			require.True(t, ok)
+			require.Equal(t, value, records[i].String()[off:off+len(value)])
+		}
+	}
+
+	f := NewLineFilter()
+	f.Update(`func:worker state:"chan receive"`)
+	require.False(t, f.Match(records[0]))
+	require.True(t, f.Match(records[1]))
+
+	f.Update(`func:worker -state:receive`)
+	require.False(t, f.Match(records[1]))
+}
+
+func TestGoroutineSummary(t *testing.T) {
+	records := testGoroutineRecords(t)
+
+	lines := GoroutineSummary(records)
+	summary := make([]string, len(lines))
+	for i, l := range lines {
+		summary[i] = l.String()
+	}
+
+	require.Equal(t, []string{
+		"3 goroutines, 2 groups",
+		"2  chan receive  main.(*pool).worker",
+		"1  running       main.crash",
+	}, summary)
+
+	require.Empty(t, GoroutineSummary(nil))
+}
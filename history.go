@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// maximum number of queries kept in the history file
+const historySize = 1000
+
+// defaultStateDir return the loon directory in the XDG state directory
+func defaultStateDir() string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		dir = expandPath("~/.local/state")
+	}
+
+	return filepath.Join(dir, "loon")
+}
+
+// History keep the committed filter queries, oldest first, in a file shared
+// between sessions. It handle the recall of previous queries and the
+// reverse incremental search over them
+type History struct {
+	path string
+
+	muHistory sync.RWMutex
+	entries   []string
+	err       error
+
+	// cursor is the index of the recalled entry, len(entries) while
+	// editing the draft
+	cursor int
+	draft  string
+
+	// reverse search state, index is the index of the current match
+	searching bool
+	pattern   string
+	index     int
+}
+
+// NewHistory load the history from the given file, a missing file is an
+// empty history. An empty path disable the history file. The history start
+// empty when the file can't be read, the error is kept for Err
+func NewHistory(path string) *History {
+	h := &History{path: path}
+	h.entries, h.err = readHistoryFile(path)
+	h.cursor = len(h.entries)
+	return h
+}
+
+func readHistoryFile(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	f, err := os.Open(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("unable to open history file: %w", err)
+	}
+	defer f.Close()
+
+	entries := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if query := scanner.Text(); query != "" {
+			entries = appendHistory(entries, query)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read history file: %w", err)
+	}
+
+	return entries, nil
+}
+
+// appendHistory append the query, removing its previous occurrence
+func appendHistory(entries []string, query string) []string {
+	for i, entry := range entries {
+		if entry == query {
+			entries = append(entries[:i], entries[i+1:]...)
+			break
+		}
+	}
+
+	entries = append(entries, query)
+	if len(entries) > historySize {
+		entries = entries[len(entries)-historySize:]
+	}
+
+	return entries
+}
+
+// Add commit the query to the history and save it. The file is read again
+// first to keep the queries of other sessions, it isn't overwritten when it
+// can't be read, the query is only kept in memory
+func (h *History) Add(query string) error {
+	h.muHistory.Lock()
+	defer h.muHistory.Unlock()
+
+	if query = strings.TrimSpace(query); query == "" {
+		return nil
+	}
+
+	entries, err := h.entries, error(nil)
+	if h.path != "" {
+		var saved []string
+		if saved, err = readHistoryFile(h.path); err == nil {
+			entries = saved
+		}
+	}
+
+	h.entries = appendHistory(entries, query)
+	h.cursor, h.draft = len(h.entries), ""
+	if h.err = err; h.err == nil {
+		h.err = h.save()
+	}
+	return h.err
+}
+
+func (h *History) save() error {
+	if h.path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o700); err != nil {
+		return fmt.Errorf("unable to create history directory: %w", err)
+	}
+
+	// queries may contain tokens or ids, keep them private
+	content := strings.Join(h.entries, "\n") + "\n"
+	if err := os.WriteFile(h.path, []byte(content), 0o600); err != nil {
+		return fmt.Errorf("unable to write history file: %w", err)
+	}
+
+	// the mode of an existing file isn't changed by WriteFile
+	if err := os.Chmod(h.path, 0o600); err != nil {
+		return fmt.Errorf("unable to change history file mode: %w", err)
+	}
+
+	return nil
+}
+
+// Err return the last error while loading or saving the history
+func (h *History) Err() (err error) {
+	h.muHistory.RLock()
+	err = h.err
+	h.muHistory.RUnlock()
+	return
+}
+
+// Entries return the queries, oldest first
+func (h *History) Entries() []string {
+	h.muHistory.RLock()
+	defer h.muHistory.RUnlock()
+	return append([]string{}, h.entries...)
+}
+
+// Prev recall the query before the recalled one, the current input is kept
+// as the draft when leaving it
+func (h *History) Prev(input string) (string, bool) {
+	h.muHistory.Lock()
+	defer h.muHistory.Unlock()
+
+	if h.cursor == 0 {
+		return "", false
+	}
+
+	if h.cursor == len(h.entries) {
+		h.draft = input
+	}
+
+	h.cursor--
+	return h.entries[h.cursor], true
+}
+
+// Next recall the query after the recalled one, then the draft
+func (h *History) Next() (string, bool) {
+	h.muHistory.Lock()
+	defer h.muHistory.Unlock()
+
+	if h.cursor >= len(h.entries) {
+		return "", false
+	}
+
+	if h.cursor++; h.cursor == len(h.entries) {
+		return h.draft, true
+	}
+
+	return h.entries[h.cursor], true
+}
+
+// Recalling return true while a previous query is recalled
+func (h *History) Recalling() (ok bool) {
+	h.muHistory.RLock()
+	ok = h.cursor < len(h.entries)
+	h.muHistory.RUnlock()
+	return
+}
+
+// ResetCursor go back to the draft, the input being edited
+func (h *History) ResetCursor() {
+	h.muHistory.Lock()
+	h.cursor = len(h.entries)
+	h.muHistory.Unlock()
+}
+
+// StartSearch start a reverse search from the newest query, the current
+// input is restored if the search is canceled
+func (h *History) StartSearch(input string) {
+	h.muHistory.Lock()
+	h.searching, h.pattern, h.index = true, "", len(h.entries)
+	h.draft = input
+	h.muHistory.Unlock()
+}
+
+// Searching return the pattern of the reverse search if any
+func (h *History) Searching() (pattern string, ok bool) {
+	h.muHistory.RLock()
+	pattern, ok = h.pattern, h.searching
+	h.muHistory.RUnlock()
+	return
+}
+
+// SearchAdd add a rune to the pattern and return the newest query matching
+// it, starting from the current match
+func (h *History) SearchAdd(r rune) (string, bool) {
+	h.muHistory.Lock()
+	defer h.muHistory.Unlock()
+
+	h.pattern += string(r)
+	if h.index < len(h.entries) {
+		h.index++ // current match included
+	}
+	return h.search()
+}
+
+// SearchDeleteBackward remove the last rune of the pattern and search again
+// from the newest query
+func (h *History) SearchDeleteBackward() (string, bool) {
+	h.muHistory.Lock()
+	defer h.muHistory.Unlock()
+
+	if _, size := utf8.DecodeLastRuneInString(h.pattern); size > 0 {
+		h.pattern = h.pattern[:len(h.pattern)-size]
+	}
+
+	h.index = len(h.entries)
+	return h.search()
+}
+
+// SearchNext return the next older query matching the pattern
+func (h *History) SearchNext() (string, bool) {
+	h.muHistory.Lock()
+	defer h.muHistory.Unlock()
+	return h.search()
+}
+
+// search look for the pattern in the queries older than the current match,
+// an empty pattern match nothing
+func (h *History) search() (string, bool) {
+	if h.pattern == "" {
+		return "", false
+	}
+
+	for i := h.index - 1; i >= 0; i-- {
+		if strings.Contains(h.entries[i], h.pattern) {
+			h.index = i
+			return h.entries[i], true
+		}
+	}
+
+	return "", false
+}
+
+// StopSearch end the reverse search and return the input before the search
+func (h *History) StopSearch() (draft string) {
+	h.muHistory.Lock()
+	h.searching, h.pattern = false, ""
+	h.cursor, draft = len(h.entries), h.draft
+	h.muHistory.Unlock()
+	return
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "loon", "history")
+
+	h := NewHistory(path)
+	require.NoError(t, h.Err())
+	require.Empty(t, h.Entries())
+
+	require.NoError(t, h.Add("error"))
+	require.NoError(t, h.Add("  "))
+	require.NoError(t, h.Add("user=bob"))
+	require.NoError(t, h.Add("error -timeout"))
+	require.NoError(t, h.Add("error"))
+	require.Equal(t, []string{"user=bob", "error -timeout", "error"}, h.Entries())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "user=bob\nerror -timeout\nerror\n", string(content))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// queries of other sessions are kept
+	other := NewHistory(path)
+	require.NoError(t, other.Err())
+	require.NoError(t, other.Add("panic"))
+	require.NoError(t, h.Add("user=bob"))
+	require.Equal(t, []string{"error -timeout", "error", "panic", "user=bob"}, h.Entries())
+}
+
+func TestHistoryUnreadable(t *testing.T) {
+	// a directory can't be read as a history file
+	h := NewHistory(t.TempDir())
+	require.Error(t, h.Err())
+	require.Empty(t, h.Entries())
+
+	// still usable in memory
+	require.Error(t, h.Add("error"))
+	require.Equal(t, []string{"error"}, h.Entries())
+}
+
+func TestHistoryLongLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	content := "error\n" + strings.Repeat("a", 1<<17) + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	// a line over the scanner limit can't be read, the file is kept as is
+	h := NewHistory(path)
+	require.Error(t, h.Err())
+	require.Error(t, h.Add("panic"))
+	require.Equal(t, []string{"panic"}, h.Entries())
+
+	saved, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, content, string(saved))
+}
+
+func TestHistoryFileMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	require.NoError(t, os.WriteFile(path, []byte("error\n"), 0o644))
+
+	h := NewHistory(path)
+	require.NoError(t, h.Add("panic"))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestHistoryRecall(t *testing.T) {
+	h := NewHistory("")
+	for _, query := range []string{"a", "b", "c"} {
+		require.NoError(t, h.Add(query))
+	}
+
+	_, ok := h.Next()
+	require.False(t, ok)
+	require.False(t, h.Recalling())
+
+	for _, expected := range []string{"c", "b", "a"} {
+		query, ok := h.Prev("draft")
+		require.True(t, ok)
+		require.Equal(t, expected, query)
+	}
+
+	_, ok = h.Prev("a")
+	require.False(t, ok)
+
+	require.True(t, h.Recalling())
+
+	for _, expected := range []string{"b", "c", "draft"} {
+		query, ok := h.Next()
+		require.True(t, ok)
+		require.Equal(t, expected, query)
+	}
+	require.False(t, h.Recalling())
+}
+
+func TestHistorySearch(t *testing.T) {
+	h := NewHistory("")
+	for _, query := range []string{"error db", "user=bob", "error api", "warn"} {
+		require.NoError(t, h.Add(query))
+	}
+
+	h.StartSearch("draft")
+	pattern, ok := h.Searching()
+	require.True(t, ok)
+	require.Empty(t, pattern)
+
+	query, ok := h.SearchAdd('e')
+	require.True(t, ok)
+	require.Equal(t, "error api", query)
+
+	// the current match is kept while it matches
+	query, ok = h.SearchAdd('r')
+	require.True(t, ok)
+	require.Equal(t, "error api", query)
+
+	query, ok = h.SearchAdd('r')
+	require.True(t, ok)
+	require.Equal(t, "error api", query)
+
+	query, ok = h.SearchNext()
+	require.True(t, ok)
+	require.Equal(t, "error db", query)
+
+	_, ok = h.SearchNext()
+	require.False(t, ok)
+
+	query, ok = h.SearchDeleteBackward()
+	require.True(t, ok)
+	require.Equal(t, "error api", query)
+
+	// the input isn't replaced once the pattern is empty
+	h.SearchDeleteBackward()
+	_, ok = h.SearchDeleteBackward()
+	require.False(t, ok)
+	_, ok = h.SearchNext()
+	require.False(t, ok)
+
+	require.Equal(t, "draft", h.StopSearch())
+	_, ok = h.Searching()
+	require.False(t, ok)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+type Level int
+
+const (
+	LevelUnknown Level = iota
+	LevelTrace
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelFatal
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelTrace:
+		return "trace"
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	case LevelFatal:
+		return "fatal"
+	default:
+		return "unknown"
+	}
+}
+
+func (l Level) Style() tcell.Style {
+	switch l {
+	case LevelFatal:
+		return tcell.StyleDefault.Foreground(tcell.ColorFuchsia).Bold(true)
+	case LevelError:
+		return tcell.StyleDefault.Foreground(tcell.ColorRed).Bold(true)
+	case LevelWarn:
+		return tcell.StyleDefault.Foreground(tcell.ColorYellow).Bold(true)
+	case LevelInfo:
+		return tcell.StyleDefault.Foreground(tcell.ColorGreen)
+	case LevelDebug, LevelTrace:
+		return tcell.StyleDefault.Foreground(tcell.ColorBlue)
+	default:
+		return tcell.StyleDefault.Bold(true)
+	}
+}
+
+// ParseLevel parse common level names case insensitively, including
+// logrus truncated names (`ERRO`, `WARN`...)
+func ParseLevel(s string) (Level, bool) {
+	switch strings.ToLower(s) {
+	case "trace", "trac", "trc":
+		return LevelTrace, true
+	case "debug", "debu", "dbg":
+		return LevelDebug, true
+	case "info", "inf", "information", "notice":
+		return LevelInfo, true
+	case "warn", "warning", "wrn":
+		return LevelWarn, true
+	case "error", "erro", "err", "eror":
+		return LevelError, true
+	case "fatal", "fata", "panic", "pani", "crit", "critical", "emerg", "emergency", "alert":
+		return LevelFatal, true
+	default:
+		return LevelUnknown, false
+	}
+}
+
+// levelField return the normalized name of the level detected at the given
+// token of the line, and the offset of the token when it's rendered as the
+// name, -1 otherwise, as for `<11>` or `E0102`
+func levelField(level Level, line string, off, size int) (string, int) {
+	name := level.String()
+	if !strings.EqualFold(line[off:off+size], name) {
+		return name, -1
+	}
+
+	return name, off
+}
+
+// SyslogSeverityLevel map a syslog severity (0-7) to a level
+func SyslogSeverityLevel(severity int) Level {
+	switch {
+	case severity <= 2: // emerg, alert, crit
+		return LevelFatal
+	case severity == 3:
+		return LevelError
+	case severity == 4:
+		return LevelWarn
+	case severity <= 6: // notice, info
+		return LevelInfo
+	default:
+		return LevelDebug
+	}
+}
+
+// only the beginning of lines is scanned for levels
+const levelDetectSize = 256
+
+var levelDetectRegexp = regexp.MustCompile(
+	// uppercase token: `ERROR`, `[WARN]`, `INFO:`
+	`(?:^|[\s\[(|])(TRACE|DEBUG|INFO|NOTICE|WARN(?:ING)?|ERR(?:OR?)?|CRIT(?:ICAL)?|FATAL|PANIC|EMERG|ALERT)(?:$|[\s\[\]):|])` +
+		// key value: `level=error`, `lvl="warn"`
+		`|\b(?:level|lvl|severity)="?([A-Za-z]+)` +
+		// json: `"level":"error"`
+		`|"(?:level|lvl|severity)"\s*:\s*"([A-Za-z]+)"` +
+		// bracketed: `[error]`, `[Warning]`
+		`|\[((?i:trace|debug|info|notice|warn|warning|error|fatal|critical))\]` +
+		// syslog priority: `<3>`
+		`|^<(\d{1,3})>` +
+		// glog: `E0102 15:04:05.000000`
+		`|^([IWEF])\d{4} `,
+)
+
+// DetectLevel look for a level token in the given line, and return its
+// position, or LevelUnknown
+func DetectLevel(line string) (level Level, off, size int) {
+	if len(line) > levelDetectSize {
+		line = line[:levelDetectSize]
+	}
+
+	match := levelDetectRegexp.FindStringSubmatchIndex(line)
+	if match == nil {
+		return LevelUnknown, -1, 0
+	}
+
+	for g := 1; g*2+1 < len(match); g++ {
+		start, end := match[g*2], match[g*2+1]
+		if start < 0 {
+			continue
+		}
+
+		token := line[start:end]
+		switch g {
+		case 5: // syslog priority
+			pri, err := strconv.Atoi(token)
+			if err != nil || pri > 191 {
+				return LevelUnknown, -1, 0
+			}
+			level = SyslogSeverityLevel(pri % 8)
+		case 6: // glog
+			level, _ = ParseLevel(map[string]string{"I": "info", "W": "warn", "E": "error", "F": "fatal"}[token])
+		default:
+			if level, _ = ParseLevel(token); level == LevelUnknown {
+				return LevelUnknown, -1, 0
+			}
+		}
+
+		return level, start, end - start
+	}
+
+	return LevelUnknown, -1, 0
+}
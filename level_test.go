@@ -0,0 +1,113 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectLevel(t *testing.T) {
+	cases := []struct {
+		Line  string
+		Level Level
+		Token string
+	}{
+		{"nothing to see here", LevelUnknown, ""},
+		{"no error here", LevelUnknown, ""},
+		{"2022/01/01 12:00:00 ERROR failed to connect", LevelError, "ERROR"},
+		{"[WARN] disk almost full", LevelWarn, "WARN"},
+		{"INFO: started", LevelInfo, "INFO"},
+		{"time=12:00 level=debug msg=foo", LevelDebug, "debug"},
+		{`time=12:00 lvl="warn" msg=foo`, LevelWarn, "warn"},
+		{`{"time": "12:00", "level": "error"}`, LevelError, "error"},
+		{"[Warning] something", LevelWarn, "Warning"},
+		{"<11>Jan  1 00:00:00 host app: failed", LevelError, "11"},
+		{"<14>Jan  1 00:00:00 host app: started", LevelInfo, "14"},
+		{"E0102 15:04:05.000000    1 main.go:10] failed", LevelError, "E"},
+		{"time=12:00 level=unknown msg=foo", LevelUnknown, ""},
+		{"ERRO[0000] logrus error", LevelError, "ERRO"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Line, func(t *testing.T) {
+			level, off, size := DetectLevel(tc.Line)
+			require.Equal(t, tc.Level, level)
+			if tc.Level != LevelUnknown {
+				require.Equal(t, tc.Token, tc.Line[off:off+size])
+			}
+		})
+	}
+}
+
+func TestFilterLevel(t *testing.T) {
+	lines := map[string]Level{
+		"DEBUG starting":         LevelDebug,
+		"INFO: started":          LevelInfo,
+		"[WARN] disk full":       LevelWarn,
+		"level=error msg=failed": LevelError,
+		"nothing":                LevelUnknown,
+	}
+
+	f := NewLineFilter()
+	for _, input := range []string{"level>=warn", "level>info"} {
+		f.Update(input)
+		require.NoError(t, f.Err())
+
+		for line, level := range lines {
+			l := ParseANSILine(line, true)
+			require.Equal(t, level >= LevelWarn, f.Match(l), "%s: %s", input, line)
+		}
+	}
+
+	t.Run("marks", func(t *testing.T) {
+		f.Update("level:warn")
+		l := ParseANSILine("[WARN] disk full", true)
+		require.True(t, f.Match(l))
+		require.Equal(t, []Mark{{0, 1, 4}}, l.marks)
+	})
+
+	t.Run("structured", func(t *testing.T) {
+		parser := &JSONParser{Fallback: &RawParser{}}
+		f.Update("level>=warn")
+		require.True(t, f.Match(parser.Parse(0, `{"level": "error", "msg": "failed"}`)))
+		require.False(t, f.Match(parser.Parse(0, `{"level": "info", "msg": "ok"}`)))
+		require.True(t, f.Match(parser.Parse(0, `not json WARNING`)))
+	})
+
+	t.Run("syslog and glog", func(t *testing.T) {
+		for _, line := range []string{
+			"<11>Jan  1 00:00:00 host app: failed",
+			"E0102 15:04:05.000000    1 main.go:10] failed",
+		} {
+			for _, input := range []string{"level:error", "level>=warn"} {
+				f.Update(input)
+				require.True(t, f.Match(ParseANSILine(line, true)), "%s: %s", input, line)
+				require.True(t, f.Match((&RawParser{}).Parse(0, line)), "%s: %s", input, line)
+			}
+
+			f.Update("level:info")
+			require.False(t, f.Match(ParseANSILine(line, true)), line)
+		}
+	})
+
+	t.Run("severity names", func(t *testing.T) {
+		syslog := &SyslogParser{Fallback: &RawParser{}}
+		json := &JSONParser{Fallback: &RawParser{}}
+		for _, l := range []Line{
+			syslog.Parse(0, "<11>Jan  1 00:00:00 host app: failed"),
+			json.Parse(0, `{"severity": "err", "msg": "failed"}`),
+			json.Parse(0, `{"level": "eror", "msg": "failed"}`),
+		} {
+			for _, input := range []string{"level:error", "severity:error", "level:ERROR", "level>=error"} {
+				f.Update(input)
+				require.True(t, f.Match(l), "%s: %s", input, l.String())
+			}
+
+			f.Update("level:warn")
+			require.False(t, f.Match(l), l.String())
+		}
+
+		f.Update("level:warning")
+		require.True(t, f.Match(syslog.Parse(0, "<12>Jan  1 00:00:00 host app: disk full")))
+	})
+}
@@ -6,18 +6,40 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 
 	"github.com/oklog/run"
 	"github.com/peterbourgon/ff/v3"
 	"github.com/peterbourgon/ff/v3/ffcli"
-	"github.com/peterbourgon/ff/v3/fftoml"
 )
 
 type LoonConfig struct {
 	RingSize   int
 	LineSize   int
 	ConfigFile string
-	// Json       bool
+	Parser     string
+	Json       bool
+	Merge      bool
+	Multiline  string
+
+	// HistoryFile keep the committed filters between sessions
+	HistoryFile string
+
+	// context lines displayed around matching lines
+	Before, After, Context int
+
+	// Formats are user defined formats, loaded from the config file
+	Formats map[string]*FormatConfig
+	// Sources map sources path patterns to a parser name
+	Sources map[string]string
+	// Columns map sources path patterns to their displayed fields
+	Columns map[string][]string
+	// SavedColumns are the columns chosen with the picker, saved in
+	// ColumnsFile, they take precedence over Columns
+	SavedColumns map[string][]string
+	ColumnsFile  string
+	// Filters are named queries from the config file
+	Filters []SavedFilter
 
 	// color
 	NoColor       bool
@@ -40,20 +62,28 @@ func parseRootConfig(args []string) (*LoonConfig, error) {
 
 	rootFlagSet.StringVar(&cfg.ConfigFile, "config", defaultLoonConfig, "root config project")
 
+	rootFlagSet.StringVar(&cfg.HistoryFile, "history", filepath.Join(defaultStateDir(), "history"), "filter history file, empty to disable")
+
 	rootFlagSet.BoolVar(&cfg.NoColor, "nocolor", false, "disable color")
 	rootFlagSet.BoolVar(&cfg.NoAnsi, "noansi", false, "do not parse ansi sequence")
 	rootFlagSet.BoolVar(&cfg.BgSourceColor, "bgcolor", false, "enable background color on multiple sources")
 	rootFlagSet.BoolVar(&cfg.FgSourceColor, "fgcolor", true, "enable forground color on multiple sources")
+	rootFlagSet.BoolVar(&cfg.Merge, "merge", false, "order the history of multiple sources by timestamp")
+	rootFlagSet.StringVar(&cfg.Multiline, "multiline", "", "fold continuation lines into records: indent, notime, re:<regex> or start:<regex>")
+	rootFlagSet.IntVar(&cfg.Before, "before", 0, "number of context lines displayed before matching lines")
+	rootFlagSet.IntVar(&cfg.After, "after", 0, "number of context lines displayed after matching lines")
+	rootFlagSet.IntVar(&cfg.Context, "context", 0, "number of context lines displayed around matching lines, same as -before and -after")
 	rootFlagSet.IntVar(&cfg.RingSize, "ringsize", 100000, "ring line capacity")
 	rootFlagSet.IntVar(&cfg.LineSize, "linesize", 10000, "If non-zero, split longer lines into multiple lines")
-	// rootFlagSet.BoolVar(&cfg.Json, "json", false, "parsed is a json line file") // @TODO
+	rootFlagSet.StringVar(&cfg.Parser, "parser", "ansi", "lines parser: ansi, raw, json, logfmt, syslog, csv, tsv, gotest, docker, cri or a format name from the config")
+	rootFlagSet.BoolVar(&cfg.Json, "json", false, "parse lines as json objects, same as -parser=json")
 	// rootFlagSet.BoolVar(&cfg.Debug, "debug", false, "debug mode") // @TODO
 
 	err := ff.Parse(rootFlagSet, args,
 		ff.WithEnvVarPrefix("LOON"),
 		ff.WithConfigFileFlag("config"),
 		ff.WithAllowMissingConfigFile(true),
-		ff.WithConfigFileParser(fftoml.Parser),
+		ff.WithConfigFileParser(configFileParser),
 	)
 
 	// expand path
@@ -61,9 +91,45 @@ func parseRootConfig(args []string) (*LoonConfig, error) {
 		return nil, fmt.Errorf("unable to parse flags: %w", err)
 	}
 
+	if cfg.Json {
+		cfg.Parser = "json"
+	}
+
+	set := map[string]bool{}
+	rootFlagSet.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if err := contextLines(&cfg, set); err != nil {
+		return nil, err
+	}
+
+	if err := loadConfigFile(&cfg); err != nil {
+		return nil, err
+	}
+
+	cfg.ColumnsFile = filepath.Join(defaultStateDir(), "columns")
+	if err := loadColumnsFile(&cfg); err != nil {
+		return nil, err
+	}
+
 	return &cfg, nil
 }
 
+// contextLines apply -context to -before and -after, unless they are set,
+// even to zero
+func contextLines(cfg *LoonConfig, set map[string]bool) error {
+	if cfg.Before < 0 || cfg.After < 0 || cfg.Context < 0 {
+		return fmt.Errorf("context lines should be positive")
+	}
+
+	if !set["before"] {
+		cfg.Before = cfg.Context
+	}
+	if !set["after"] {
+		cfg.After = cfg.Context
+	}
+
+	return nil
+}
+
 func main() {
 	// disable logger
 	log.SetOutput(os.Stderr)
@@ -76,7 +142,7 @@ func main() {
 	}
 
 	root := &ffcli.Command{
-		Name:    "loon [flags] <files...>",
+		Name:    "loon [flags] <[parser:]files...>",
 		FlagSet: rootFlagSet,
 		Exec: func(ctx context.Context, args []string) error {
 			readers := []Reader{}
@@ -99,7 +165,10 @@ func main() {
 			// check for files
 			{
 				for _, arg := range args {
-					file := NewFile(arg, false)
+					parser, path := splitParserArg(lcfg, arg)
+					file := NewFile(path, false)
+					file.Parser = parser
+
 					reader, err := NewReader(lcfg, file)
 					if err != nil {
 						return fmt.Errorf("unable to create reader from `%s`: %w", arg, err)
@@ -117,7 +186,22 @@ func main() {
 			case 1:
 				reader = readers[0]
 			default:
-				reader = NewMultiReader(readers...)
+				if lcfg.Merge {
+					sources := []File{}
+					for _, r := range readers {
+						sources = append(sources, r.Sources()...)
+					}
+
+					// the screen has its own parser
+					parser, err := NewSourceParser(lcfg, sources, false)
+					if err != nil {
+						return err
+					}
+
+					reader = NewMergeReader(parser, readers...)
+				} else {
+					reader = NewMultiReader(readers...)
+				}
 			}
 
 			s, err := NewScreen(lcfg, reader)
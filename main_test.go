@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextLines(t *testing.T) {
+	cases := []struct {
+		Name                   string
+		Before, After, Context int
+		Set                    []string
+		Expected               [2]int
+	}{
+		{"none", 0, 0, 0, nil, [2]int{0, 0}},
+		{"context", 0, 0, 5, []string{"context"}, [2]int{5, 5}},
+		{"before", 2, 0, 5, []string{"context", "before"}, [2]int{2, 5}},
+		{"before disabled", 0, 0, 5, []string{"context", "before"}, [2]int{0, 5}},
+		{"after only", 0, 3, 0, []string{"after"}, [2]int{0, 3}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			cfg := &LoonConfig{Before: tc.Before, After: tc.After, Context: tc.Context}
+			set := map[string]bool{}
+			for _, name := range tc.Set {
+				set[name] = true
+			}
+
+			require.NoError(t, contextLines(cfg, set))
+			require.Equal(t, tc.Expected, [2]int{cfg.Before, cfg.After})
+		})
+	}
+
+	require.Error(t, contextLines(&LoonConfig{Before: -1}, nil))
+}
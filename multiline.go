@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MultilineRule decide if the given line continue the record made of the
+// given lines
+type MultilineRule func(record []Line, l Line) bool
+
+// NewMultilineRule create a rule from its spec:
+//
+//	indent          lines starting with a whitespace
+//	notime          lines not starting with a timestamp
+//	re:<regex>      lines matching the regex
+//	start:<regex>   lines not matching the regex, which match record starts
+func NewMultilineRule(spec string) (MultilineRule, error) {
+	kind, pattern, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "":
+		return nil, nil
+	case "indent":
+		return multilineIndent, nil
+	case "notime":
+		return multilineNoTime, nil
+	case "re", "start":
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid multiline regex: %w", err)
+		}
+
+		start := kind == "start"
+		return func(_ []Line, l Line) bool {
+			return re.MatchString(l.String()) != start
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown multiline rule `%s`", spec)
+	}
+}
+
+func multilineIndent(_ []Line, l Line) bool {
+	s := l.String()
+	return s != "" && (s[0] == ' ' || s[0] == '\t')
+}
+
+// multilineNoTime only fold lines into records starting with a timestamp,
+// so logs without timestamps aren't folded into a single record
+func multilineNoTime(record []Line, l Line) bool {
+	if t, _, _ := record[0].Time(); t.IsZero() {
+		return false
+	}
+
+	// timestamps may be preceded by a bracket or a syslog priority
+	s := l.String()
+	switch t, off, _ := DetectTime(s); {
+	case t.IsZero():
+		return true
+	case off == 0:
+		return false
+	default:
+		return strings.ContainsAny(s[:off], " \t")
+	}
+}
+
+// Fold fold the given line into the previous entry of the same source, it
+// return the record replacing the previous entry
+func (rule MultilineRule) Fold(prev, l Line) (Line, bool) {
+	if event, ok := l.(*GoTestLine); ok {
+		return foldGoTest(prev, event)
+	}
+
+	record, ok := prev.(*Record)
+
+	var lines []Line
+	if ok {
+		lines = record.Lines()
+	} else {
+		lines = []Line{prev}
+	}
+
+	if len(lines) >= maxRecordLines || !rule(lines, l) {
+		return nil, false
+	}
+
+	if record == nil {
+		record = NewRecord(prev)
+	}
+
+	record.Append(l)
+	return record, true
+}
+
+// FoldKey return the key of the line, lines are folded into the previous
+// line of the same source and key
+func FoldKey(l Line) string {
+	if k, ok := l.(interface{ FoldKey() string }); ok {
+		return k.FoldKey()
+	}
+
+	return ""
+}
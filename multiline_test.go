@@ -0,0 +1,153 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testFoldLines(t *testing.T, rule MultilineRule, lines ...string) [][]string {
+	t.Helper()
+
+	var entries []Line
+	for _, s := range lines {
+		l := ParseANSILine(s, false)
+		if len(entries) > 0 {
+			if folded, ok := rule.Fold(entries[len(entries)-1], l); ok {
+				entries[len(entries)-1] = folded
+				continue
+			}
+		}
+		entries = append(entries, l)
+	}
+
+	result := make([][]string, len(entries))
+	for i, e := range entries {
+		if ml, ok := e.(MultiLine); ok {
+			for _, l := range ml.Lines() {
+				result[i] = append(result[i], l.String())
+			}
+			continue
+		}
+		result[i] = []string{e.String()}
+	}
+
+	return result
+}
+
+func TestMultilineRule(t *testing.T) {
+	t.Run("indent", func(t *testing.T) {
+		rule, err := NewMultilineRule("indent")
+		require.NoError(t, err)
+		require.Equal(t, [][]string{
+			{"Exception in thread main", "\tat Foo.bar(Foo.java:42)", "    at Foo.main(Foo.java:12)"},
+			{"Caused by: NullPointerException"},
+			{""},
+		}, testFoldLines(t, rule,
+			"Exception in thread main",
+			"\tat Foo.bar(Foo.java:42)",
+			"    at Foo.main(Foo.java:12)",
+			"Caused by: NullPointerException",
+			"",
+		))
+	})
+
+	t.Run("notime", func(t *testing.T) {
+		rule, err := NewMultilineRule("notime")
+		require.NoError(t, err)
+		require.Equal(t, [][]string{
+			{"no time"},
+			{"still no time"},
+			{
+				"2022-01-02 15:04:05,123 ERROR failed",
+				"Traceback (most recent call last):",
+				`  File "main.py", line 2, in <module>`,
+				"ValueError: 2022-01-02 15:04:05",
+			},
+			{"[2022-01-02 15:04:06] INFO next"},
+		}, testFoldLines(t, rule,
+			"no time",
+			"still no time",
+			"2022-01-02 15:04:05,123 ERROR failed",
+			"Traceback (most recent call last):",
+			`  File "main.py", line 2, in <module>`,
+			"ValueError: 2022-01-02 15:04:05",
+			"[2022-01-02 15:04:06] INFO next",
+		))
+	})
+
+	t.Run("regex", func(t *testing.T) {
+		rule, err := NewMultilineRule(`re:^(\s|Caused by:)`)
+		require.NoError(t, err)
+		require.Equal(t, [][]string{
+			{"Exception", "\tat Foo.bar", "Caused by: NullPointerException"},
+			{"next"},
+		}, testFoldLines(t, rule, "Exception", "\tat Foo.bar", "Caused by: NullPointerException", "next"))
+	})
+
+	t.Run("start", func(t *testing.T) {
+		rule, err := NewMultilineRule(`start:^\[`)
+		require.NoError(t, err)
+		require.Equal(t, [][]string{
+			{"[1] first", "continued", "  indented"},
+			{"[2] second"},
+		}, testFoldLines(t, rule, "[1] first", "continued", "  indented", "[2] second"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewMultilineRule("unknown")
+		require.Error(t, err)
+		_, err = NewMultilineRule("re:(")
+		require.Error(t, err)
+
+		rule, err := NewMultilineRule("")
+		require.NoError(t, err)
+		require.Nil(t, rule)
+	})
+}
+
+func TestRecordMarks(t *testing.T) {
+	record := NewRecord(ParseANSILine("first line", false))
+	record.Append(ParseANSILine("second line", false))
+	require.Equal(t, "first line\nsecond line", record.String())
+	require.Equal(t, 11, record.Len())
+
+	f := NewLineFilter()
+	f.NextMode() // regex
+	f.Update(`line\nsecond`)
+	require.True(t, f.Match(record))
+
+	lines := record.Lines()
+	require.Equal(t, []Mark{{0, 6, 4}}, testLineMarks(lines[0]))
+	require.Equal(t, []Mark{{0, 0, 6}}, testLineMarks(lines[1]))
+
+	f.Update("nothing")
+	require.False(t, f.Match(record))
+	require.Empty(t, testLineMarks(lines[0]))
+}
+
+func TestFileRowsTallRecord(t *testing.T) {
+	record := NewRecord(ParseANSILine("2022-01-02T15:04:05Z ERROR panic", false))
+	for _, l := range []string{"\tframe 1", "\tframe 2", "\tframe 3", "\tframe 4", "\tframe 5"} {
+		record.Append(ParseANSILine(l, false))
+	}
+
+	f := &FileComponent{}
+	lines := []Line{ParseANSILine("before", false), record}
+
+	// following, the first line of the record replaces the cut lines
+	rows := f.rows(lines, nil, -1, 4, true)
+	require.Len(t, rows, 4)
+	require.Equal(t, record, rows[0].line)
+	require.Equal(t, 2, rows[0].folded)
+	for i, expected := range []string{"\tframe 3", "\tframe 4", "\tframe 5"} {
+		require.Equal(t, expected, rows[i+1].line.String())
+	}
+
+	// the oldest rows are kept otherwise
+	rows = f.rows(lines, nil, -1, 4, false)
+	require.Len(t, rows, 4)
+	require.Equal(t, "before", rows[0].line.String())
+	require.Equal(t, "2022-01-02T15:04:05Z ERROR panic", rows[1].line.String())
+	require.Zero(t, rows[1].folded)
+}
@@ -1,5 +1,186 @@
 package main
 
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
 type Parser[output any] interface {
 	Parse(sid SourceID, line string) output
 }
+
+var parserNames = []string{"ansi", "raw", "json", "logfmt", "gotest", "docker", "cri", "syslog", "csv", "tsv"}
+
+// NewParser create a parser by name, structured parsers fallback on the
+// ansi parser, or the raw parser if ansi is disabled
+func NewParser(lcfg *LoonConfig, name string, sourceColor bool) (Parser[Line], error) {
+	var fallback Parser[Line]
+	if lcfg.NoAnsi {
+		fallback = &RawParser{}
+	} else {
+		fallback = &ANSIParser{
+			NoColor:     lcfg.NoColor,
+			SourceColor: sourceColor,
+		}
+	}
+
+	switch name {
+	case "ansi", "":
+		return fallback, nil
+	case "raw":
+		return &RawParser{}, nil
+	case "json":
+		return &JSONParser{
+			NoColor:     lcfg.NoColor,
+			SourceColor: sourceColor,
+			Fallback:    fallback,
+		}, nil
+	case "logfmt":
+		return &LogfmtParser{
+			NoColor:     lcfg.NoColor,
+			SourceColor: sourceColor,
+			Fallback:    fallback,
+		}, nil
+	case "syslog":
+		return &SyslogParser{
+			NoColor:     lcfg.NoColor,
+			SourceColor: sourceColor,
+			Fallback:    fallback,
+		}, nil
+	case "csv", "tsv":
+		return NewTableParser(name, lcfg.NoColor, sourceColor), nil
+	case "docker", "cri":
+		return &ContainerParser{
+			Format:  name,
+			Message: fallback,
+		}, nil
+	case "gotest":
+		return &GoTestParser{
+			NoColor:     lcfg.NoColor,
+			SourceColor: sourceColor,
+			Fallback:    fallback,
+		}, nil
+	}
+
+	if format, ok := lcfg.Formats[name]; ok {
+		return &FormatParser{
+			NoColor:     lcfg.NoColor,
+			SourceColor: sourceColor,
+			Format:      format,
+			Fallback:    fallback,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown parser `%s`, available parsers: %s", name, strings.Join(availableParsers(lcfg), ", "))
+}
+
+func availableParsers(lcfg *LoonConfig) []string {
+	names := append([]string{}, parserNames...)
+	for format := range lcfg.Formats {
+		names = append(names, format)
+	}
+	sort.Strings(names[len(parserNames):])
+	return names
+}
+
+// splitParserArg split a `parser:path` argument, the prefix is only
+// considered if it's a known parser name
+func splitParserArg(lcfg *LoonConfig, arg string) (parser, path string) {
+	if i := strings.IndexByte(arg, ':'); i > 0 {
+		if containsString(availableParsers(lcfg), arg[:i]) {
+			return arg[:i], arg[i+1:]
+		}
+	}
+
+	return "", arg
+}
+
+// sourceParserName return the parser name of the given source: the one
+// given on the command line, then the one of the most specific matching
+// pattern in the config, then the default one
+func sourceParserName(lcfg *LoonConfig, f File) string {
+	if f.Parser != "" {
+		return f.Parser
+	}
+
+	if glob := sourcePattern(lcfg.Sources, f); glob != "" {
+		return lcfg.Sources[glob]
+	}
+
+	return lcfg.Parser
+}
+
+// sourcePattern return the most specific pattern matching the path, or the
+// base name, of the given source, patterns of the same length are ordered
+// lexically
+func sourcePattern[V any](patterns map[string]V, f File) (glob string) {
+	for pattern := range patterns {
+		switch {
+		case len(pattern) < len(glob):
+			continue
+		case len(pattern) == len(glob) && pattern >= glob:
+			continue
+		}
+
+		if ok, _ := filepath.Match(pattern, f.Path); ok {
+			glob = pattern
+		} else if ok, _ := filepath.Match(pattern, filepath.Base(f.Path)); ok {
+			glob = pattern
+		}
+	}
+
+	return glob
+}
+
+// SourceParser dispatch lines to the parser of their source
+type SourceParser struct {
+	Default Parser[Line]
+	Parsers map[SourceID]Parser[Line]
+}
+
+// NewSourceParser create the parser of each given sources, a single parser
+// is returned if all sources share the same parser
+func NewSourceParser(lcfg *LoonConfig, sources []File, sourceColor bool) (Parser[Line], error) {
+	byname := map[string]Parser[Line]{}
+	parsers := map[SourceID]Parser[Line]{}
+
+	for _, f := range sources {
+		name := sourceParserName(lcfg, f)
+		parser, ok := byname[name]
+		if !ok {
+			var err error
+			if parser, err = NewParser(lcfg, name, sourceColor); err != nil {
+				return nil, fmt.Errorf("unable to create parser for `%s`: %w", f.Path, err)
+			}
+			byname[name] = parser
+		}
+
+		parsers[f.ID] = parser
+	}
+
+	def, ok := byname[lcfg.Parser]
+	if !ok {
+		var err error
+		if def, err = NewParser(lcfg, lcfg.Parser, sourceColor); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(byname) == 1 {
+		for _, parser := range byname {
+			return parser, nil
+		}
+	}
+
+	return &SourceParser{Default: def, Parsers: parsers}, nil
+}
+
+func (p *SourceParser) Parse(sid SourceID, line string) Line {
+	if parser, ok := p.Parsers[sid]; ok {
+		return parser.Parse(sid, line)
+	}
+
+	return p.Default.Parse(sid, line)
+}
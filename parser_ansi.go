@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"hash/fnv"
 	"strings"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 	ansi "github.com/leaanthony/go-ansi-parser"
@@ -20,6 +21,14 @@ type ANSILine struct {
 	bgcol   tcell.Color
 	seqs    []*lineSequence
 	marks   []Mark
+
+	// detected level token
+	level              Level
+	levelOff, levelLen int
+
+	// detected timestamp
+	time             time.Time
+	timeOff, timeLen int
 }
 
 func ParseANSILine(line string, color bool) *ANSILine {
@@ -41,11 +50,60 @@ func ParseANSILine(line string, color bool) *ANSILine {
 		}
 	} else {
 		l.content.WriteString(line)
+		l.seqs = []*lineSequence{{Style: tcell.StyleDefault, Size: l.content.Len()}}
+	}
+
+	l.time, l.timeOff, l.timeLen = DetectTime(l.content.String())
+	l.level, l.levelOff, l.levelLen = DetectLevel(l.content.String())
+	if color && l.level != LevelUnknown {
+		l.restyle(l.levelOff, l.levelLen, l.level.Style())
 	}
 
 	return &l
 }
 
+// restyle apply the given style on the given range, parts of the range
+// which already have a foreground color are left untouched
+func (l *ANSILine) restyle(off, size int, style tcell.Style) {
+	end := off + size
+	seqs := make([]*lineSequence, 0, len(l.seqs)+2)
+	for _, s := range l.seqs {
+		from, to := s.Index, s.Index+s.Size
+		fg, _, _ := s.Style.Decompose()
+		if to <= off || from >= end || fg != tcell.ColorDefault {
+			seqs = append(seqs, s)
+			continue
+		}
+
+		if from < off {
+			seqs = append(seqs, &lineSequence{Style: s.Style, Index: from, Size: off - from})
+			from = off
+		}
+
+		split := to
+		if split > end {
+			split = end
+		}
+
+		seqs = append(seqs, &lineSequence{Style: style, Index: from, Size: split - from})
+		if split < to {
+			seqs = append(seqs, &lineSequence{Style: s.Style, Index: split, Size: to - split})
+		}
+	}
+
+	l.seqs = seqs
+}
+
+// write append the given string to the line with the given style
+func (l *ANSILine) write(style tcell.Style, str string) {
+	l.seqs = append(l.seqs, &lineSequence{
+		Style: style,
+		Index: l.content.Len(),
+		Size:  len(str),
+	})
+	l.content.WriteString(str)
+}
+
 func (l *ANSILine) printSeqs(p Printer, content []byte, x, y, width, offset int) (pl int) {
 	for _, s := range l.seqs {
 		from, to := s.Index, s.Index+s.Size
@@ -107,6 +165,20 @@ func (l *ANSILine) String() string {
 	return l.content.String()
 }
 
+// Field only expose the detected level of the line, by its name
+func (l *ANSILine) Field(key string) (string, int, bool) {
+	if l.level != LevelUnknown && containsString(structuredLevelKeys, strings.ToLower(key)) {
+		value, off := levelField(l.level, l.content.String(), l.levelOff, l.levelLen)
+		return value, off, true
+	}
+
+	return "", -1, false
+}
+
+func (l *ANSILine) Time() (time.Time, int, int) {
+	return l.time, l.timeOff, l.timeLen
+}
+
 func (l *ANSILine) Len() int {
 	return l.content.Len()
 }
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// containerEntry is a line of a container log file, wrapped in a docker
+// json-file or a CRI envelope
+type containerEntry struct {
+	Time    time.Time
+	RawTime string
+	Stream  string
+	Message string
+
+	// Partial is true if the message continue on the next entry
+	Partial bool
+}
+
+// parseCRIEntry parse `<time> <stream> <P|F>[:flags] <message>`
+func parseCRIEntry(line string) (e containerEntry, ok bool) {
+	parts := strings.SplitN(line, " ", 4)
+	if len(parts) < 3 {
+		return e, false
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil || (parts[1] != "stdout" && parts[1] != "stderr") {
+		return e, false
+	}
+
+	tag, _, _ := strings.Cut(parts[2], ":")
+	if tag != "P" && tag != "F" {
+		return e, false
+	}
+
+	e = containerEntry{Time: t, RawTime: parts[0], Stream: parts[1], Partial: tag == "P"}
+	if len(parts) == 4 {
+		e.Message = parts[3]
+	}
+
+	return e, true
+}
+
+func (e containerEntry) criLine() string {
+	return e.RawTime + " " + e.Stream + " F " + e.Message
+}
+
+type dockerLogEntry struct {
+	Log    string `json:"log"`
+	Stream string `json:"stream"`
+	Time   string `json:"time"`
+}
+
+// parseDockerEntry parse `{"log":"<message>\n","stream":"stdout","time":"<time>"}`,
+// messages without new line are partial
+func parseDockerEntry(line string) (e containerEntry, ok bool) {
+	if !strings.HasPrefix(line, "{") {
+		return e, false
+	}
+
+	var entry dockerLogEntry
+	if err := json.Unmarshal([]byte(line), &entry); err != nil || entry.Stream == "" {
+		return e, false
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, entry.Time)
+	if err != nil {
+		return e, false
+	}
+
+	return containerEntry{
+		Time:    t,
+		RawTime: entry.Time,
+		Stream:  entry.Stream,
+		Message: strings.TrimRight(entry.Log, "\r\n"),
+		Partial: !strings.HasSuffix(entry.Log, "\n"),
+	}, true
+}
+
+func (e containerEntry) dockerLine() string {
+	line, _ := json.Marshal(&dockerLogEntry{
+		Log:    e.Message + "\n",
+		Stream: e.Stream,
+		Time:   e.RawTime,
+	})
+	return string(line)
+}
+
+// ContainerLine is the message of a container log entry, the stream and the
+// time of the entry are kept as fields
+type ContainerLine struct {
+	Line
+
+	stream, rawTime string
+	time            time.Time
+}
+
+func (l *ContainerLine) Field(key string) (string, int, bool) {
+	key = strings.ToLower(key)
+	switch {
+	case key == "stream":
+		return l.stream, -1, true
+	case containsString(structuredTimeKeys, key):
+		return l.rawTime, -1, true
+	default:
+		return l.Line.Field(key)
+	}
+}
+
+func (l *ContainerLine) Time() (time.Time, int, int) {
+	return l.time, -1, 0
+}
+
+// ContainerParser unwrap docker json-file or CRI entries
+type ContainerParser struct {
+	// Format is either `docker` or `cri`
+	Format string
+
+	// Message is used to parse messages, and lines that aren't container
+	// log entries
+	Message Parser[Line]
+}
+
+func (p *ContainerParser) Parse(sid SourceID, line string) Line {
+	e, ok := parseContainerEntry(p.Format, line)
+	if !ok {
+		return p.Message.Parse(sid, line)
+	}
+
+	return &ContainerLine{
+		Line:    p.Message.Parse(sid, e.Message),
+		stream:  e.Stream,
+		rawTime: e.RawTime,
+		time:    e.Time,
+	}
+}
+
+func parseContainerEntry(format, line string) (containerEntry, bool) {
+	if format == "docker" {
+		return parseDockerEntry(line)
+	}
+
+	return parseCRIEntry(line)
+}
+
+func isContainerParser(name string) bool {
+	return name == "docker" || name == "cri"
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainerParser(t *testing.T) {
+	expected := time.Date(2024, 1, 1, 0, 0, 0, 123e6, time.UTC)
+
+	cases := []struct {
+		Format, Line    string
+		Message, Stream string
+	}{
+		{"cri", "2024-01-01T00:00:00.123Z stdout F level=info msg=started", "level=info msg=started", "stdout"},
+		{"cri", "2024-01-01T00:00:00.123Z stderr F:extra failed", "failed", "stderr"},
+		{"cri", "2024-01-01T00:00:00.123Z stdout F", "", "stdout"},
+		{"docker", `{"log":"started\n","stream":"stdout","time":"2024-01-01T00:00:00.123Z"}`, "started", "stdout"},
+		{"docker", `{"log":"\u001b[31mfailed\u001b[0m\n","stream":"stderr","time":"2024-01-01T00:00:00.123Z"}`, "failed", "stderr"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Line, func(t *testing.T) {
+			p := &ContainerParser{Format: tc.Format, Message: &ANSIParser{}}
+			l := p.Parse(0, tc.Line)
+			require.IsType(t, &ContainerLine{}, l)
+			require.Equal(t, tc.Message, l.String())
+
+			stream, _, ok := l.Field("stream")
+			require.True(t, ok)
+			require.Equal(t, tc.Stream, stream)
+
+			ts, _, _ := l.Time()
+			require.True(t, expected.Equal(ts))
+		})
+	}
+
+	t.Run("not an entry", func(t *testing.T) {
+		p := &ContainerParser{Format: "cri", Message: &ANSIParser{}}
+		for _, line := range []string{"plain line", "2024-01-01T00:00:00Z stdin F foo", "yesterday stdout F foo"} {
+			l := p.Parse(0, line)
+			require.IsType(t, &ANSILine{}, l)
+			require.Equal(t, line, l.String())
+		}
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		p := &ContainerParser{Format: "cri", Message: &ANSIParser{}}
+		f := NewLineFilter()
+		f.Update("stream:stderr AND failed")
+		require.True(t, f.Match(p.Parse(0, "2024-01-01T00:00:00Z stderr F failed")))
+		require.False(t, f.Match(p.Parse(0, "2024-01-01T00:00:00Z stdout F failed")))
+	})
+}
+
+func TestPartialReader(t *testing.T) {
+	t.Run("cri", func(t *testing.T) {
+		r := NewPartialReader(&testLinesReader{lines: []string{
+			"2024-01-01T00:00:00Z stdout P hello ",
+			"2024-01-01T00:00:01Z stderr F failed",
+			"2024-01-01T00:00:02Z stdout P wor",
+			"2024-01-01T00:00:03Z stdout F ld",
+			"not an entry",
+			"2024-01-01T00:00:04Z stdout F done",
+		}}, "cri")
+
+		for _, expected := range []string{
+			"2024-01-01T00:00:01Z stderr F failed",
+			"2024-01-01T00:00:00Z stdout F hello world",
+			"not an entry",
+			"2024-01-01T00:00:04Z stdout F done",
+		} {
+			line, _, err := r.Readline()
+			require.NoError(t, err)
+			require.Equal(t, expected, line)
+		}
+	})
+
+	t.Run("docker", func(t *testing.T) {
+		r := NewPartialReader(&testLinesReader{lines: []string{
+			`{"log":"hello ","stream":"stdout","time":"2024-01-01T00:00:00Z"}`,
+			`{"log":"world\n","stream":"stdout","time":"2024-01-01T00:00:01Z"}`,
+		}}, "docker")
+
+		line, _, err := r.Readline()
+		require.NoError(t, err)
+		require.Equal(t, `{"log":"hello world\n","stream":"stdout","time":"2024-01-01T00:00:00Z"}`, line)
+	})
+	t.Run("over linesize", func(t *testing.T) {
+		// docker writes partial entries of 16KiB
+		chunk, last := strings.Repeat("a", 16384), strings.Repeat("b", 100)
+		path := filepath.Join(t.TempDir(), "container.log")
+		err := os.WriteFile(path, []byte(strings.Join([]string{
+			`{"log":"` + chunk + `","stream":"stdout","time":"2024-01-01T00:00:00Z"}`,
+			`{"log":"` + last + `\n","stream":"stdout","time":"2024-01-01T00:00:01Z"}`,
+		}, "\n")+"\n"), 0o600)
+		require.NoError(t, err)
+
+		expected := `{"log":"` + chunk + last + `\n","stream":"stdout","time":"2024-01-01T00:00:00Z"}`
+		for _, merge := range []bool{false, true} {
+			lcfg := &LoonConfig{RingSize: 100, LineSize: 10000, Merge: merge}
+			f := NewFile(path, false)
+			f.Parser = "docker"
+
+			r, err := NewReader(lcfg, f)
+			require.NoError(t, err)
+
+			line, _, err := r.Readline()
+			require.NoError(t, err)
+			require.Equal(t, expected, line, "merge=%v", merge)
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/pelletier/go-toml"
+)
+
+// FormatConfig is a user defined format, declared in the config file as:
+//
+//	[format.nginx]
+//	regex = '^(?P<remote>\S+) \S+ \S+ \[(?P<time>[^\]]+)\] "(?P<method>\S+) (?P<path>\S+)[^"]*" (?P<status>\d+)'
+//
+//	[format.nginx.colors]
+//	status = "red"
+//	method = "#ffaa00"
+type FormatConfig struct {
+	Name   string
+	Regex  *regexp.Regexp
+	Colors map[string]tcell.Color
+}
+
+func parseFormatConfig(name string, tree *toml.Tree) (*FormatConfig, error) {
+	pattern, ok := tree.Get("regex").(string)
+	if !ok {
+		return nil, fmt.Errorf("missing `regex` key")
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex: %w", err)
+	}
+
+	var named bool
+	for _, group := range re.SubexpNames() {
+		named = named || group != ""
+	}
+
+	if !named {
+		return nil, fmt.Errorf("regex has no named capture group")
+	}
+
+	format := &FormatConfig{
+		Name:   name,
+		Regex:  re,
+		Colors: map[string]tcell.Color{},
+	}
+
+	if colors, ok := tree.Get("colors").(*toml.Tree); ok {
+		for _, field := range colors.Keys() {
+			value, ok := colors.Get(field).(string)
+			if !ok {
+				return nil, fmt.Errorf("color of `%s` should be a string", field)
+			}
+
+			color := tcell.GetColor(strings.ToLower(value))
+			if color == tcell.ColorDefault {
+				return nil, fmt.Errorf("unknown color `%s` for `%s`", value, field)
+			}
+
+			format.Colors[field] = color
+		}
+	}
+
+	return format, nil
+}
+
+type FormatParser struct {
+	NoColor     bool
+	SourceColor bool
+	Format      *FormatConfig
+
+	// Fallback is used to parse lines that don't match the format
+	Fallback Parser[Line]
+}
+
+func (p *FormatParser) Parse(sid SourceID, line string) Line {
+	match := p.Format.Regex.FindStringSubmatchIndex(line)
+	if match == nil {
+		return p.Fallback.Parse(sid, line)
+	}
+
+	l := &StructuredLine{ANSILine: &ANSILine{sid: sid}}
+	if p.SourceColor {
+		l.bgcol = sid.Color(0.75)
+	}
+
+	// the line is kept as is, captured fields are colored
+	var pos int
+	for i, name := range p.Format.Regex.SubexpNames() {
+		start, end := match[i*2], match[i*2+1]
+		if name == "" || start < 0 {
+			continue
+		}
+
+		l.fields = append(l.fields, &lineField{Key: name, Value: line[start:end], off: start})
+
+		// nested groups are not colored
+		if start < pos {
+			continue
+		}
+
+		l.write(tcell.StyleDefault, line[pos:start])
+		l.write(p.fieldStyle(name, line[start:end]), line[start:end])
+		pos = end
+	}
+
+	l.write(tcell.StyleDefault, line[pos:])
+
+	if !l.parseTime() {
+		l.time, l.timeOff, l.timeLen = DetectTime(line)
+	}
+
+	return l
+}
+
+func (p *FormatParser) fieldStyle(name, value string) tcell.Style {
+	if p.NoColor {
+		return tcell.StyleDefault
+	}
+
+	if color, ok := p.Format.Colors[name]; ok {
+		return tcell.StyleDefault.Foreground(color)
+	}
+
+	key := strings.ToLower(name)
+	switch {
+	case containsString(structuredTimeKeys, key):
+		return structuredTimeStyle
+	case containsString(structuredLevelKeys, key):
+		return structuredLevelStyle(strings.ToUpper(value))
+	default:
+		return tcell.StyleDefault
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/pelletier/go-toml"
+	"github.com/stretchr/testify/require"
+)
+
+const testFormatConfig = `
+[format.nginx]
+regex = '^(?P<remote>\S+) \S+ \S+ \[(?P<time>[^\]]+)\] "(?P<method>\S+) (?P<path>\S+)[^"]*" (?P<status>\d+) (?P<bytes>\d+)'
+
+[format.nginx.colors]
+status = "red"
+method = "#ffaa00"
+`
+
+func TestFormatParser(t *testing.T) {
+	tree, err := toml.Load(testFormatConfig)
+	require.NoError(t, err)
+
+	format, err := parseFormatConfig("nginx", tree.GetPath([]string{"format", "nginx"}).(*toml.Tree))
+	require.NoError(t, err)
+	require.Equal(t, tcell.ColorRed, format.Colors["status"])
+
+	parser := &FormatParser{Format: format, Fallback: &RawParser{}}
+
+	t.Run("match", func(t *testing.T) {
+		raw := `127.0.0.1 - - [10/Oct/2022:13:55:36 +0000] "GET /index.html HTTP/1.1" 503 2326`
+		line := parser.Parse(0, raw)
+		require.Equal(t, raw, line.String())
+
+		for key, expected := range map[string]string{
+			"remote": "127.0.0.1",
+			"method": "GET",
+			"path":   "/index.html",
+			"status": "503",
+		} {
+			value, off, ok := line.Field(key)
+			require.True(t, ok)
+			require.Equal(t, expected, value)
+			require.Equal(t, expected, raw[off:off+len(value)])
+		}
+
+		f := NewLineFilter()
+		f.Update("status>=500 AND method:get")
+		require.True(t, f.Match(line))
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		line := parser.Parse(0, "not an access log")
+		_, ok := line.(*RawLine)
+		require.True(t, ok)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, config := range []string{
+			`regex = '^(\S+)$'`,
+			`regex = '^(?P<a>\S+$'`,
+			`color = "red"`,
+			"regex = '^(?P<a>\\S+)$'\n[colors]\na = \"notacolor\"",
+		} {
+			tree, err := toml.Load(config)
+			require.NoError(t, err)
+			_, err = parseFormatConfig("invalid", tree)
+			require.Error(t, err, config)
+		}
+	})
+}
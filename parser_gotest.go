@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// GoTestEvent is an event of `go test -json`, see `go doc test2json`
+type GoTestEvent struct {
+	Time    time.Time
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+}
+
+var (
+	goTestPassStyle = tcell.StyleDefault.Foreground(tcell.ColorGreen).Bold(true)
+	goTestFailStyle = tcell.StyleDefault.Foreground(tcell.ColorRed).Bold(true)
+	goTestSkipStyle = tcell.StyleDefault.Foreground(tcell.ColorYellow).Bold(true)
+	goTestRunStyle  = tcell.StyleDefault.Foreground(tcell.ColorBlue).Bold(true)
+)
+
+// GoTestLine is a single event, output events are rendered as is, other
+// events as a status line
+type GoTestLine struct {
+	*ANSILine
+
+	event GoTestEvent
+	color bool
+}
+
+type GoTestParser struct {
+	NoColor     bool
+	SourceColor bool
+
+	// Fallback is used to parse lines that aren't test2json events
+	Fallback Parser[Line]
+}
+
+func (p *GoTestParser) Parse(sid SourceID, line string) Line {
+	var event GoTestEvent
+	if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &event) != nil || event.Action == "" {
+		return p.Fallback.Parse(sid, line)
+	}
+
+	l := &GoTestLine{event: event, color: !p.NoColor}
+	if event.Action == "output" {
+		l.ANSILine = ParseANSILine(strings.TrimRight(event.Output, "\r\n"), !p.NoColor)
+	} else {
+		l.ANSILine, _ = renderGoTestStatus(event.Action, event.Package, event.Test, event.Elapsed, !p.NoColor)
+	}
+
+	l.sid = sid
+	l.time, l.timeOff, l.timeLen = event.Time, -1, 0
+	if p.SourceColor {
+		l.bgcol = sid.Color(0.75)
+	}
+
+	return l
+}
+
+// FoldKey group events by package and test
+func (l *GoTestLine) FoldKey() string {
+	return l.event.Package + " " + l.event.Test
+}
+
+func (l *GoTestLine) Field(key string) (string, int, bool) {
+	if value, ok := l.event.field(key); ok {
+		return value, -1, true
+	}
+
+	return l.ANSILine.Field(key)
+}
+
+func (e *GoTestEvent) field(key string) (string, bool) {
+	switch strings.ToLower(key) {
+	case "package", "pkg":
+		return e.Package, true
+	case "test":
+		return e.Test, e.Test != ""
+	case "action":
+		return e.Action, true
+	case "elapsed":
+		return goTestElapsed(e.Elapsed).String(), e.Elapsed > 0
+	default:
+		return "", false
+	}
+}
+
+// GoTestRecord group the output of a test, or of a package, under a status
+// line updated as the events come
+type GoTestRecord struct {
+	*Record
+
+	muStatus  sync.RWMutex
+	pkg, test string
+	status    string
+	elapsed   float64
+	color     bool
+	sid       SourceID
+	bgcol     tcell.Color
+	time      time.Time
+
+	// offset of the test in the status line
+	testOff int
+}
+
+func newGoTestRecord(first *GoTestLine) *GoTestRecord {
+	r := &GoTestRecord{
+		pkg:    first.event.Package,
+		test:   first.event.Test,
+		status: "run",
+		color:  first.color,
+		sid:    first.sid,
+		bgcol:  first.bgcol,
+		time:   first.event.Time,
+	}
+
+	r.Record = NewRecord(r.header())
+	r.apply(first)
+	return r
+}
+
+// apply add output events to the record, and update the status line on
+// other events
+func (r *GoTestRecord) apply(l *GoTestLine) bool {
+	if l.event.Action == "output" {
+		if len(r.Lines()) >= maxRecordLines {
+			return false
+		}
+
+		r.Append(l)
+		return true
+	}
+
+	r.muStatus.Lock()
+	switch l.event.Action {
+	case "pass", "fail", "skip":
+		r.status, r.elapsed = l.event.Action, l.event.Elapsed
+	case "run", "cont", "start":
+		r.status = "run"
+	}
+	r.muStatus.Unlock()
+
+	r.Replace(0, r.header())
+	return true
+}
+
+func (r *GoTestRecord) header() Line {
+	r.muStatus.Lock()
+	l, testOff := renderGoTestStatus(r.status, r.pkg, r.test, r.elapsed, r.color)
+	l.sid, l.bgcol, r.testOff = r.sid, r.bgcol, testOff
+	r.muStatus.Unlock()
+	return l
+}
+
+func (r *GoTestRecord) Time() (time.Time, int, int) {
+	return r.time, -1, 0
+}
+
+func (r *GoTestRecord) Field(key string) (string, int, bool) {
+	r.muStatus.RLock()
+	defer r.muStatus.RUnlock()
+
+	switch strings.ToLower(key) {
+	case "status":
+		return r.status, 0, true
+	case "package", "pkg":
+		return r.pkg, -1, true
+	case "test":
+		return r.test, r.testOff, r.test != ""
+	case "elapsed":
+		return goTestElapsed(r.elapsed).String(), -1, r.elapsed > 0
+	}
+
+	return r.first().Field(key)
+}
+
+// foldGoTest fold an event into the record of its test
+func foldGoTest(prev Line, l *GoTestLine) (Line, bool) {
+	var record *GoTestRecord
+	switch p := prev.(type) {
+	case *GoTestRecord:
+		record = p
+	case *GoTestLine:
+		record = newGoTestRecord(p)
+	default:
+		return nil, false
+	}
+
+	if record.pkg != l.event.Package || record.test != l.event.Test || !record.apply(l) {
+		return nil, false
+	}
+
+	return record, true
+}
+
+// renderGoTestStatus render `<STATUS> <test> (<elapsed>) <package>`, and
+// return the offset of the test
+func renderGoTestStatus(status, pkg, test string, elapsed float64, color bool) (*ANSILine, int) {
+	style := func(s tcell.Style) tcell.Style {
+		if color {
+			return s
+		}
+		return tcell.StyleDefault
+	}
+
+	l := &ANSILine{timeOff: -1, levelOff: -1}
+	switch status {
+	case "pass":
+		l.write(style(goTestPassStyle), "PASS")
+	case "fail":
+		l.write(style(goTestFailStyle), "FAIL")
+	case "skip":
+		l.write(style(goTestSkipStyle), "SKIP")
+	default:
+		l.write(style(goTestRunStyle), strings.ToUpper(fmt.Sprintf("%-4s", status)))
+	}
+
+	testOff := -1
+	if test != "" {
+		l.write(tcell.StyleDefault, " ")
+		testOff = l.content.Len()
+		l.write(tcell.StyleDefault.Bold(color), test)
+	}
+
+	if elapsed > 0 {
+		l.write(style(structuredTimeStyle), fmt.Sprintf(" (%s)", goTestElapsed(elapsed)))
+	}
+
+	l.write(tcell.StyleDefault, " ")
+	l.write(style(structuredKeyStyle), pkg)
+	return l, testOff
+}
+
+func goTestElapsed(elapsed float64) time.Duration {
+	return time.Duration(elapsed * float64(time.Second)).Round(time.Millisecond)
+}
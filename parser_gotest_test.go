@@ -0,0 +1,102 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testGoTestEvents = []string{
+	`{"Time":"2022-01-02T15:04:05Z","Action":"start","Package":"example.com/pkg"}`,
+	`{"Time":"2022-01-02T15:04:05Z","Action":"run","Package":"example.com/pkg","Test":"TestOk"}`,
+	`{"Time":"2022-01-02T15:04:05Z","Action":"output","Package":"example.com/pkg","Test":"TestOk","Output":"=== RUN   TestOk\n"}`,
+	`{"Time":"2022-01-02T15:04:05Z","Action":"run","Package":"example.com/pkg","Test":"TestFail"}`,
+	`{"Time":"2022-01-02T15:04:05Z","Action":"output","Package":"example.com/pkg","Test":"TestFail","Output":"=== RUN   TestFail\n"}`,
+	`{"Time":"2022-01-02T15:04:05Z","Action":"output","Package":"example.com/pkg","Test":"TestOk","Output":"--- PASS: TestOk (0.01s)\n"}`,
+	`{"Time":"2022-01-02T15:04:05Z","Action":"pass","Package":"example.com/pkg","Test":"TestOk","Elapsed":0.01}`,
+	`{"Time":"2022-01-02T15:04:06Z","Action":"output","Package":"example.com/pkg","Test":"TestFail","Output":"    main_test.go:12: expected 42\n"}`,
+	`{"Time":"2022-01-02T15:04:06Z","Action":"output","Package":"example.com/pkg","Test":"TestFail","Output":"--- FAIL: TestFail (1.20s)\n"}`,
+	`{"Time":"2022-01-02T15:04:06Z","Action":"fail","Package":"example.com/pkg","Test":"TestFail","Elapsed":1.2}`,
+	`{"Time":"2022-01-02T15:04:06Z","Action":"output","Package":"example.com/pkg","Output":"FAIL\n"}`,
+	`{"Time":"2022-01-02T15:04:06Z","Action":"fail","Package":"example.com/pkg","Elapsed":1.3}`,
+}
+
+func TestGoTestParser(t *testing.T) {
+	p := &GoTestParser{Fallback: &RawParser{}}
+
+	l := p.Parse(0, testGoTestEvents[2])
+	require.IsType(t, &GoTestLine{}, l)
+	require.Equal(t, "=== RUN   TestOk", l.String())
+	require.Equal(t, "example.com/pkg TestOk", FoldKey(l))
+
+	value, _, ok := l.Field("test")
+	require.True(t, ok)
+	require.Equal(t, "TestOk", value)
+
+	l = p.Parse(0, testGoTestEvents[9])
+	require.Equal(t, "FAIL TestFail (1.2s) example.com/pkg", l.String())
+
+	l = p.Parse(0, "# example.com/pkg [build failed]")
+	require.IsType(t, &RawLine{}, l)
+	require.Equal(t, "", FoldKey(l))
+}
+
+func TestGoTestRecords(t *testing.T) {
+	rule, err := NewMultilineRule("")
+	require.NoError(t, err)
+
+	filter := NewLineFilter()
+	bw := NewBufferWindow[Line](10, &BufferWindowOptions[Line]{
+		Reader:  &testLinesReader{lines: testGoTestEvents},
+		Filter:  filter.Match,
+		Parser:  &GoTestParser{NoColor: true, Fallback: &RawParser{}},
+		Buffer:  NewBuffer[Line](100),
+		Fold:    rule.withGoroutines().Fold,
+		FoldKey: FoldKey,
+	})
+	bw.sync = true
+
+	filter.Update("status:fail")
+	for range testGoTestEvents {
+		_, err := bw.Readline()
+		require.NoError(t, err)
+	}
+
+	// package, TestOk and TestFail
+	require.Equal(t, uint(3), bw.Lines())
+
+	view := bw.Slice()
+	require.Len(t, view, 2)
+
+	record, ok := view[0].(*GoTestRecord)
+	require.True(t, ok)
+	require.Equal(t, []string{"FAIL (1.3s) example.com/pkg", "FAIL"}, testLinesString(record.Lines()))
+
+	record, ok = view[1].(*GoTestRecord)
+	require.True(t, ok)
+	require.Equal(t, []string{
+		"FAIL TestFail (1.2s) example.com/pkg",
+		"=== RUN   TestFail",
+		"    main_test.go:12: expected 42",
+		"--- FAIL: TestFail (1.20s)",
+	}, testLinesString(record.Lines()))
+	require.Equal(t, []Mark{{0, 0, 4}}, testLineMarks(record.Lines()[0]))
+
+	value, off, ok := record.Field("test")
+	require.True(t, ok)
+	require.Equal(t, "TestFail", record.String()[off:off+len(value)])
+
+	filter.Update("elapsed<1s")
+	bw.Refresh()
+	view = bw.Slice()
+	require.Len(t, view, 1)
+	require.Equal(t, "PASS TestOk (10ms) example.com/pkg", view[0].(MultiLine).Lines()[0].String())
+}
+
+func testLinesString(lines []Line) []string {
+	strs := make([]string, len(lines))
+	for i, l := range lines {
+		strs[i] = l.String()
+	}
+	return strs
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type JSONParser struct {
+	NoColor     bool
+	SourceColor bool
+
+	// Fallback is used to parse lines that aren't json objects
+	Fallback Parser[Line]
+}
+
+func (p *JSONParser) Parse(sid SourceID, line string) Line {
+	fields, err := parseJSONFields(line)
+	if err != nil || len(fields) == 0 {
+		return p.Fallback.Parse(sid, line)
+	}
+
+	sline := NewStructuredLine(sid, fields, !p.NoColor)
+	if p.SourceColor {
+		sline.bgcol = sid.Color(0.75)
+	}
+
+	return sline
+}
+
+// parseJSONFields decode a json object into a list of fields, keeping the
+// original keys order. Nested objects are flattened using dotted keys.
+func parseJSONFields(line string) ([]*lineField, error) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		return nil, fmt.Errorf("not a json object")
+	}
+
+	dec := json.NewDecoder(strings.NewReader(line))
+	dec.UseNumber()
+
+	fields := []*lineField{}
+	if err := decodeJSONObject(dec, "", &fields); err != nil {
+		return nil, err
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("trailing data after json object")
+	}
+
+	return fields, nil
+}
+
+func decodeJSONObject(dec *json.Decoder, prefix string, fields *[]*lineField) error {
+	if tok, err := dec.Token(); err != nil {
+		return err
+	} else if tok != json.Delim('{') {
+		return fmt.Errorf("not a json object")
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		key, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("invalid json key: %v", tok)
+		}
+		key = prefix + key
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+
+		switch raw[0] {
+		case '{':
+			sub := json.NewDecoder(bytes.NewReader(raw))
+			sub.UseNumber()
+			if err := decodeJSONObject(sub, key+".", fields); err != nil {
+				return err
+			}
+		case '"':
+			var value string
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return err
+			}
+			*fields = append(*fields, &lineField{Key: key, Value: value, quoted: true})
+		default:
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, raw); err != nil {
+				return err
+			}
+			*fields = append(*fields, &lineField{Key: key, Value: compact.String()})
+		}
+	}
+
+	// consume closing delimiter
+	_, err := dec.Token()
+	return err
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONParser(t *testing.T) {
+	parser := &JSONParser{NoColor: true, Fallback: &RawParser{}}
+
+	cases := []struct {
+		Name, Line, Expected string
+	}{
+		{"raw", "not json", "not json"},
+		{"invalid", `{"msg": "foo"`, `{"msg": "foo"`},
+		{"trailing", `{"msg": "foo"} bar`, `{"msg": "foo"} bar`},
+		{"empty", `{}`, `{}`},
+		{"message", `{"msg": "started"}`, "started"},
+		{"ordered",
+			`{"status": 200, "level": "info", "msg": "request", "time": "2022-01-01T00:00:00Z", "path": "/"}`,
+			"2022-01-01T00:00:00Z INFO request status=200 path=/",
+		},
+		{"quoted", `{"msg": "x", "err": "connection reset", "empty": ""}`, `x err="connection reset" empty=""`},
+		{"nested", `{"user": {"id": 42, "name": "foo"}, "tags": ["a", "b"], "ok": true, "v": null}`,
+			`user.id=42 user.name=foo tags=["a","b"] ok=true v=null`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			line := parser.Parse(0, tc.Line)
+			require.Equal(t, tc.Expected, line.String())
+		})
+	}
+}
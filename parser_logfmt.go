@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type LogfmtParser struct {
+	NoColor     bool
+	SourceColor bool
+
+	// Fallback is used to parse lines that aren't logfmt
+	Fallback Parser[Line]
+}
+
+func (p *LogfmtParser) Parse(sid SourceID, line string) Line {
+	fields, err := parseLogfmtFields(line)
+	if err != nil {
+		return p.Fallback.Parse(sid, line)
+	}
+
+	sline := NewStructuredLine(sid, fields, !p.NoColor)
+	if p.SourceColor {
+		sline.bgcol = sid.Color(0.75)
+	}
+
+	return sline
+}
+
+// parseLogfmtFields parse `key=value key="quoted value" bare` pairs, a line
+// is considered as logfmt if it contains at least two `key=value` pairs, or
+// a single one with a time, level or message key
+func parseLogfmtFields(line string) ([]*lineField, error) {
+	fields := []*lineField{}
+
+	var pairs int
+	var known bool
+	for i := 0; i < len(line); {
+		if c := line[i]; c == ' ' || c == '\t' {
+			i++
+			continue
+		}
+
+		// read key
+		start := i
+		for ; i < len(line) && line[i] > ' ' && line[i] != '=' && line[i] != '"'; i++ {
+		}
+
+		key := line[start:i]
+		if key == "" {
+			return nil, fmt.Errorf("invalid key at %d", start)
+		}
+
+		// bare key
+		if i >= len(line) || line[i] != '=' {
+			if i < len(line) && line[i] == '"' {
+				return nil, fmt.Errorf("unexpected quote at %d", i)
+			}
+
+			fields = append(fields, &lineField{Key: key, bare: true})
+			continue
+		}
+
+		// read value
+		i++
+		pairs++
+		known = known || isLogfmtKnownKey(key)
+		if i < len(line) && line[i] == '"' {
+			value, size, err := readLogfmtQuoted(line[i:])
+			if err != nil {
+				return nil, err
+			}
+
+			fields = append(fields, &lineField{Key: key, Value: value, quoted: true})
+			i += size
+			continue
+		}
+
+		start = i
+		for ; i < len(line) && line[i] != ' ' && line[i] != '\t'; i++ {
+		}
+
+		fields = append(fields, &lineField{Key: key, Value: line[start:i]})
+	}
+
+	if pairs == 0 || pairs == 1 && !known {
+		return nil, fmt.Errorf("not enough key=value pairs")
+	}
+
+	return fields, nil
+}
+
+func isLogfmtKnownKey(key string) bool {
+	key = strings.ToLower(key)
+	return containsString(structuredTimeKeys, key) ||
+		containsString(structuredLevelKeys, key) ||
+		containsString(structuredMessageKeys, key)
+}
+
+// readLogfmtQuoted read and unescape a quoted value, returning its size in
+// the given string
+func readLogfmtQuoted(s string) (value string, size int, err error) {
+	var escaped bool
+	for size = 1; size < len(s); size++ {
+		switch {
+		case escaped:
+			escaped = false
+		case s[size] == '\\':
+			escaped = true
+		case s[size] == '"':
+			size++
+			if value, err = strconv.Unquote(s[:size]); err != nil {
+				// unknown escape sequences are kept as is
+				value = strings.ReplaceAll(s[1:size-1], `\"`, `"`)
+				err = nil
+			}
+
+			return value, size, nil
+		}
+	}
+
+	return "", 0, fmt.Errorf("unterminated quoted value")
+}
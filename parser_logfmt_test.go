@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogfmtParser(t *testing.T) {
+	parser := &LogfmtParser{NoColor: true, Fallback: &RawParser{}}
+
+	cases := []struct {
+		Name, Line, Expected string
+	}{
+		{"raw", "not logfmt", "not logfmt"},
+		{"unterminated", `msg="foo`, `msg="foo`},
+		{"single pair", `retry=3 failed`, `retry=3 failed`},
+		{"single known pair", `failed level=warn`, `WARN failed`},
+		{"simple", `level=info msg="started" took=3ms`, "INFO started took=3ms"},
+		{"ordered", `took=3ms msg=request level=warn ts=2022-01-01T00:00:00Z`, "2022-01-01T00:00:00Z WARN request took=3ms"},
+		{"quoted", `msg=x err="connection reset" empty=""`, `x err="connection reset" empty=""`},
+		{"escapes", `msg="say \"hi\"\tnow" path="C:\dir"`, `say "hi"` + "\t" + `now path=C:\dir`},
+		{"bare keys", `msg=x debug enabled=true`, `x debug enabled=true`},
+		{"unquoted equal", `msg=x url=/a?b=c`, `x url=/a?b=c`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			line := parser.Parse(0, tc.Line)
+			require.Equal(t, tc.Expected, line.String())
+		})
+	}
+
+	t.Run("fields", func(t *testing.T) {
+		line := parser.Parse(0, `level=error msg="request failed" status=503 debug`)
+
+		value, _, ok := line.Field("status")
+		require.True(t, ok)
+		require.Equal(t, "503", value)
+
+		value, off, ok := line.Field("msg")
+		require.True(t, ok)
+		require.Equal(t, "request failed", value)
+		require.Equal(t, "request failed", line.String()[off:off+len(value)])
+
+		_, _, ok = line.Field("debug")
+		require.True(t, ok)
+	})
+}
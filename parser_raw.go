@@ -1,6 +1,11 @@
 package main
 
-import "github.com/gdamore/tcell/v2"
+import (
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
 
 type rawMark struct {
 	off, len int
@@ -9,10 +14,21 @@ type rawMark struct {
 type RawLine struct {
 	sid  SourceID
 	line string
+
+	// detected level token
+	level              Level
+	levelOff, levelLen int
+
+	// detected timestamp
+	time             time.Time
+	timeOff, timeLen int
 }
 
 func ParseRawLine(sid SourceID, line string) *RawLine {
-	return &RawLine{sid, line}
+	l := &RawLine{sid: sid, line: line}
+	l.time, l.timeOff, l.timeLen = DetectTime(line)
+	l.level, l.levelOff, l.levelLen = DetectLevel(line)
+	return l
 }
 
 func (l *RawLine) Print(p Printer, x, y, width, offset int) {
@@ -39,6 +55,20 @@ func (l *RawLine) Source() SourceID {
 func (l *RawLine) SetMarks(marks ...Mark) {
 }
 
+// Field only expose the detected level of the line, by its name
+func (l *RawLine) Field(key string) (string, int, bool) {
+	if l.level != LevelUnknown && containsString(structuredLevelKeys, strings.ToLower(key)) {
+		value, off := levelField(l.level, l.line, l.levelOff, l.levelLen)
+		return value, off, true
+	}
+
+	return "", -1, false
+}
+
+func (l *RawLine) Time() (time.Time, int, int) {
+	return l.time, l.timeOff, l.timeLen
+}
+
 func (l *RawLine) Len() int {
 	return len(l.line)
 }
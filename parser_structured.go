@@ -0,0 +1,205 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+var (
+	structuredTimeKeys    = []string{"time", "ts", "timestamp", "@timestamp", "date", "datetime"}
+	structuredLevelKeys   = []string{"level", "lvl", "severity", "loglevel", "@level"}
+	structuredMessageKeys = []string{"msg", "message", "@message"}
+)
+
+var (
+	structuredTimeStyle  = tcell.StyleDefault.Foreground(tcell.ColorGray)
+	structuredKeyStyle   = tcell.StyleDefault.Foreground(tcell.ColorDarkCyan)
+	structuredEqualStyle = tcell.StyleDefault.Foreground(tcell.ColorGray)
+)
+
+type lineField struct {
+	Key, Value string
+
+	// quoted is true if the value was a string, it's rendered quoted when
+	// it contains spaces
+	quoted bool
+
+	// bare is true for key without value
+	bare bool
+
+	// offset of the value in the rendered line, -1 if the value isn't
+	// rendered as is
+	off int
+}
+
+// StructuredLine is a line made of fields, rendered as
+// `<time> <level> <message> key=value...`
+type StructuredLine struct {
+	*ANSILine
+
+	fields []*lineField
+}
+
+func NewStructuredLine(sid SourceID, fields []*lineField, color bool) *StructuredLine {
+	l := &StructuredLine{
+		ANSILine: &ANSILine{sid: sid},
+		fields:   fields,
+	}
+
+	for _, f := range fields {
+		f.off = -1
+	}
+
+	style := func(s tcell.Style) tcell.Style {
+		if color {
+			return s
+		}
+		return tcell.StyleDefault
+	}
+
+	var timef, levelf, msgf *lineField
+	rest := []*lineField{}
+	for _, f := range fields {
+		key := strings.ToLower(f.Key)
+		switch {
+		case f.bare:
+			rest = append(rest, f)
+		case timef == nil && containsString(structuredTimeKeys, key):
+			timef = f
+		case levelf == nil && containsString(structuredLevelKeys, key):
+			levelf = f
+		case msgf == nil && containsString(structuredMessageKeys, key):
+			msgf = f
+		default:
+			rest = append(rest, f)
+		}
+	}
+
+	sep := func() {
+		if l.content.Len() > 0 {
+			l.write(tcell.StyleDefault, " ")
+		}
+	}
+
+	if timef != nil {
+		l.writeValue(timef, style(structuredTimeStyle), timef.Value)
+	}
+
+	if levelf != nil {
+		sep()
+		level := strings.ToUpper(levelf.Value)
+		l.writeValue(levelf, style(structuredLevelStyle(level)), level)
+	}
+
+	if msgf != nil {
+		sep()
+		l.writeValue(msgf, tcell.StyleDefault, msgf.Value)
+	}
+
+	for _, f := range rest {
+		sep()
+		if f.bare {
+			l.write(style(structuredKeyStyle), f.Key)
+			continue
+		}
+
+		l.write(style(structuredKeyStyle), f.Key)
+		l.write(style(structuredEqualStyle), "=")
+		l.writeValue(f, tcell.StyleDefault, f.renderValue())
+	}
+
+	l.parseTime()
+	return l
+}
+
+// parseTime parse the first time field which holds a valid timestamp
+func (l *StructuredLine) parseTime() bool {
+	for _, f := range l.fields {
+		if f.bare || !containsString(structuredTimeKeys, strings.ToLower(f.Key)) {
+			continue
+		}
+
+		t, ok := ParseTime(f.Value)
+		if !ok {
+			continue
+		}
+
+		l.time, l.timeOff, l.timeLen = t, f.off, 0
+		if f.off >= 0 {
+			l.timeLen = len(f.Value)
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// writeValue write the rendered value of the given field, and keep track
+// of its offset when the value can be found as is in the rendered string
+func (l *StructuredLine) writeValue(f *lineField, style tcell.Style, rendered string) {
+	off := l.content.Len()
+	switch {
+	case len(rendered) == len(f.Value) && strings.EqualFold(rendered, f.Value):
+		f.off = off
+	case rendered == `"`+f.Value+`"`:
+		f.off = off + 1
+	}
+
+	l.write(style, rendered)
+}
+
+// Field lookup the given key, falling back on a case insensitive match,
+// then on well known aliases such as `msg` for `message`
+func (l *StructuredLine) Field(key string) (string, int, bool) {
+	for _, f := range l.fields {
+		if f.Key == key {
+			return f.Value, f.off, true
+		}
+	}
+
+	for _, f := range l.fields {
+		if strings.EqualFold(f.Key, key) {
+			return f.Value, f.off, true
+		}
+	}
+
+	key = strings.ToLower(key)
+	for _, aliases := range [][]string{structuredTimeKeys, structuredLevelKeys, structuredMessageKeys} {
+		if !containsString(aliases, key) {
+			continue
+		}
+
+		for _, f := range l.fields {
+			if containsString(aliases, strings.ToLower(f.Key)) {
+				return f.Value, f.off, true
+			}
+		}
+	}
+
+	return "", -1, false
+}
+
+func (f *lineField) renderValue() string {
+	if f.quoted && (f.Value == "" || strings.ContainsAny(f.Value, " \t\"=")) {
+		return strconv.Quote(f.Value)
+	}
+
+	return f.Value
+}
+
+func structuredLevelStyle(value string) tcell.Style {
+	level, _ := ParseLevel(value)
+	return level.Style()
+}
+
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
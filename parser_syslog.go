@@ -0,0 +1,342 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+var syslogSeverityNames = []string{
+	"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug",
+}
+
+var syslogFacilityNames = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "clock",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+var syslogStructuredDataStyle = tcell.StyleDefault.Foreground(tcell.ColorGray)
+
+// SyslogParser parse RFC5424 and RFC3164 lines, with or without priority:
+//
+//	<165>1 2003-10-11T22:14:15.003Z host app 42 ID47 [id@32473 key="value"] message
+//	<34>Oct 11 22:14:15 host su[42]: message
+//	Oct 11 22:14:15 host su: message
+//
+// The line is kept as is, its parts are exposed as fields
+type SyslogParser struct {
+	NoColor     bool
+	SourceColor bool
+
+	// Fallback is used to parse lines that aren't syslog
+	Fallback Parser[Line]
+}
+
+func (p *SyslogParser) Parse(sid SourceID, line string) Line {
+	b := &syslogBuilder{
+		l:     &StructuredLine{ANSILine: &ANSILine{sid: sid}},
+		line:  line,
+		color: !p.NoColor,
+	}
+
+	if err := b.parse(); err != nil {
+		return p.Fallback.Parse(sid, line)
+	}
+
+	if p.SourceColor {
+		b.l.bgcol = sid.Color(0.75)
+	}
+
+	return b.l
+}
+
+type syslogBuilder struct {
+	l     *StructuredLine
+	line  string
+	color bool
+
+	// pos is the parsing position, written is the position up to which
+	// the line has been written
+	pos, written int
+
+	level Level
+}
+
+func (b *syslogBuilder) parse() error {
+	if err := b.parsePriority(); err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(b.line[b.pos:], "1 ") {
+		b.pos += 2
+		if err := b.parseRFC5424(); err != nil {
+			return err
+		}
+	} else if err := b.parseRFC3164(); err != nil {
+		return err
+	}
+
+	// without priority, the level is looked up in the message
+	if b.level == LevelUnknown && b.pos < len(b.line) {
+		if level, off, size := DetectLevel(b.line[b.pos:]); level != LevelUnknown {
+			b.level = level
+			b.field("level", b.pos+off, b.pos+off+size, level.Style())
+		}
+	}
+
+	b.field("msg", b.pos, len(b.line), tcell.StyleDefault)
+	b.l.parseTime()
+	return nil
+}
+
+func (b *syslogBuilder) parsePriority() error {
+	if !strings.HasPrefix(b.line, "<") {
+		return nil
+	}
+
+	end := strings.IndexByte(b.line, '>')
+	if end < 2 || end > 4 {
+		return fmt.Errorf("invalid priority")
+	}
+
+	pri, err := strconv.Atoi(b.line[1:end])
+	if err != nil || pri > 191 {
+		return fmt.Errorf("invalid priority")
+	}
+
+	severity := pri % 8
+	b.level = SyslogSeverityLevel(severity)
+
+	b.write(0, end+1, b.style(b.level.Style()))
+	b.l.fields = append(b.l.fields,
+		&lineField{Key: "priority", Value: b.line[1:end], off: 1},
+		&lineField{Key: "facility", Value: syslogFacilityNames[pri/8], off: -1},
+		&lineField{Key: "severity", Value: syslogSeverityNames[severity], off: -1},
+	)
+
+	b.pos = end + 1
+	return nil
+}
+
+// parseRFC5424 parse `TIMESTAMP HOSTNAME APP-NAME PROCID MSGID SD [MSG]`,
+// nil values (`-`) are skipped
+func (b *syslogBuilder) parseRFC5424() error {
+	for _, key := range []string{"time", "hostname", "appname", "procid", "msgid"} {
+		start, end, ok := b.token()
+		if !ok {
+			return fmt.Errorf("missing %s", key)
+		}
+
+		if b.line[start:end] != "-" {
+			b.field(key, start, end, b.fieldStyle(key))
+		}
+	}
+
+	if !strings.HasPrefix(b.line[b.pos:], " ") {
+		return fmt.Errorf("missing structured data")
+	}
+
+	b.pos++
+	if err := b.parseStructuredData(); err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(b.line[b.pos:], " ") {
+		b.pos++
+	}
+
+	// skip utf8 bom
+	b.pos += len(b.line[b.pos:]) - len(strings.TrimPrefix(b.line[b.pos:], "\uFEFF"))
+	return nil
+}
+
+// parseStructuredData parse `-` or `[id key="value"...]...`, params are
+// exposed as `id.key` fields
+func (b *syslogBuilder) parseStructuredData() error {
+	if strings.HasPrefix(b.line[b.pos:], "-") {
+		b.pos++
+		return nil
+	}
+
+	start := b.pos
+	for strings.HasPrefix(b.line[b.pos:], "[") {
+		b.pos++
+		idStart := b.pos
+		for b.pos < len(b.line) && b.line[b.pos] != ' ' && b.line[b.pos] != ']' {
+			b.pos++
+		}
+
+		id := b.line[idStart:b.pos]
+		if id == "" || b.pos >= len(b.line) {
+			return fmt.Errorf("invalid structured data")
+		}
+
+		// params: ` key="value"`
+		for b.line[b.pos] == ' ' {
+			b.pos++
+			eq := strings.Index(b.line[b.pos:], `="`)
+			if eq <= 0 || strings.ContainsAny(b.line[b.pos:b.pos+eq], " ]") {
+				return fmt.Errorf("invalid structured data param")
+			}
+
+			key := id + "." + b.line[b.pos:b.pos+eq]
+			b.pos += eq + 2
+
+			value, size, err := readSyslogParamValue(b.line[b.pos:])
+			if err != nil {
+				return err
+			}
+
+			f := &lineField{Key: key, Value: value, quoted: true, off: -1}
+			if size == len(value)+1 { // no escaped character
+				f.off = b.pos
+			}
+
+			b.l.fields = append(b.l.fields, f)
+			if b.pos += size; b.pos >= len(b.line) {
+				return fmt.Errorf("unterminated structured data")
+			}
+		}
+
+		if b.line[b.pos] != ']' {
+			return fmt.Errorf("invalid structured data")
+		}
+		b.pos++
+	}
+
+	if b.pos == start {
+		return fmt.Errorf("invalid structured data")
+	}
+
+	b.write(start, b.pos, b.style(syslogStructuredDataStyle))
+	return nil
+}
+
+// readSyslogParamValue read a param value up to its closing quote, `"`,
+// `\` and `]` may be escaped
+func readSyslogParamValue(s string) (value string, size int, err error) {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte(`"\]`, s[i+1]) >= 0:
+			i++
+			sb.WriteByte(s[i])
+		case c == '"':
+			return sb.String(), i + 1, nil
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return "", 0, fmt.Errorf("unterminated param value")
+}
+
+// parseRFC3164 parse `TIMESTAMP HOSTNAME TAG[PID]: MSG`, the timestamp is
+// either `Mmm dd hh:mm:ss` or a RFC3339 timestamp
+func (b *syslogBuilder) parseRFC3164() error {
+	start := b.pos
+	if len(b.line)-start >= len(time.Stamp) {
+		if _, err := time.Parse(time.Stamp, b.line[start:start+len(time.Stamp)]); err == nil {
+			b.pos += len(time.Stamp)
+		}
+	}
+
+	if b.pos == start {
+		_, end, ok := b.token()
+		if !ok {
+			return fmt.Errorf("missing timestamp")
+		}
+
+		if _, err := time.Parse(time.RFC3339Nano, b.line[start:end]); err != nil {
+			return fmt.Errorf("invalid timestamp")
+		}
+	}
+
+	b.field("time", start, b.pos, b.fieldStyle("time"))
+
+	host, end, ok := b.token()
+	if !ok {
+		return fmt.Errorf("missing hostname")
+	}
+	b.field("hostname", host, end, b.fieldStyle("hostname"))
+
+	// tag: `app[pid]:` or `app:`
+	tag, end, ok := b.token()
+	if !ok || !strings.HasSuffix(b.line[tag:end], ":") {
+		return fmt.Errorf("missing tag")
+	}
+
+	app := b.line[tag : end-1]
+	if i := strings.IndexByte(app, '['); i > 0 && strings.HasSuffix(app, "]") {
+		b.field("appname", tag, tag+i, b.fieldStyle("appname"))
+		b.field("procid", tag+i+1, end-2, b.fieldStyle("procid"))
+	} else {
+		b.field("appname", tag, end-1, b.fieldStyle("appname"))
+	}
+
+	if strings.HasPrefix(b.line[b.pos:], " ") {
+		b.pos++
+	}
+
+	return nil
+}
+
+// token read the next space separated token
+func (b *syslogBuilder) token() (start, end int, ok bool) {
+	for b.pos < len(b.line) && b.line[b.pos] == ' ' {
+		b.pos++
+	}
+
+	start = b.pos
+	for b.pos < len(b.line) && b.line[b.pos] != ' ' {
+		b.pos++
+	}
+
+	return start, b.pos, b.pos > start
+}
+
+// field add a field rendered as is, and style it
+func (b *syslogBuilder) field(key string, start, end int, style tcell.Style) {
+	b.l.fields = append(b.l.fields, &lineField{Key: key, Value: b.line[start:end], off: start})
+	b.write(start, end, b.style(style))
+}
+
+// write write the line up to the given range, then the range with the given
+// style
+func (b *syslogBuilder) write(start, end int, style tcell.Style) {
+	if start > b.written {
+		b.l.write(tcell.StyleDefault, b.line[b.written:start])
+	}
+
+	if end > b.written {
+		if start < b.written {
+			start = b.written
+		}
+		b.l.write(style, b.line[start:end])
+		b.written = end
+	}
+}
+
+func (b *syslogBuilder) fieldStyle(key string) tcell.Style {
+	switch key {
+	case "time":
+		return structuredTimeStyle
+	case "hostname":
+		return structuredKeyStyle
+	case "appname":
+		return tcell.StyleDefault.Bold(true)
+	default:
+		return tcell.StyleDefault
+	}
+}
+
+func (b *syslogBuilder) style(s tcell.Style) tcell.Style {
+	if b.color {
+		return s
+	}
+	return tcell.StyleDefault
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyslogParser(t *testing.T) {
+	p := &SyslogParser{Fallback: &RawParser{}}
+
+	t.Run("rfc5424", func(t *testing.T) {
+		line := `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3" eventSource="Application"] An application event`
+		l := p.Parse(0, line)
+		require.IsType(t, &StructuredLine{}, l)
+		require.Equal(t, line, l.String())
+
+		for key, expected := range map[string]string{
+			"priority":                      "165",
+			"facility":                      "local4",
+			"severity":                      "notice",
+			"hostname":                      "mymachine.example.com",
+			"appname":                       "evntslog",
+			"msgid":                         "ID47",
+			"exampleSDID@32473.iut":         "3",
+			"exampleSDID@32473.eventSource": "Application",
+			"msg":                           "An application event",
+		} {
+			value, off, ok := l.Field(key)
+			require.True(t, ok, key)
+			require.Equal(t, expected, value, key)
+			if off >= 0 {
+				require.Equal(t, expected, line[off:off+len(value)], key)
+			}
+		}
+
+		_, _, ok := l.Field("procid")
+		require.False(t, ok)
+
+		ts, _, _ := l.Time()
+		require.True(t, time.Date(2003, 10, 11, 22, 14, 15, 3e6, time.UTC).Equal(ts))
+	})
+
+	t.Run("rfc5424 escaped param", func(t *testing.T) {
+		l := p.Parse(0, `<14>1 - host app 42 - [id a="x\"y\]" b="z"][other c="1"]`)
+		require.IsType(t, &StructuredLine{}, l)
+
+		value, off, ok := l.Field("id.a")
+		require.True(t, ok)
+		require.Equal(t, `x"y]`, value)
+		require.Equal(t, -1, off)
+
+		value, _, _ = l.Field("other.c")
+		require.Equal(t, "1", value)
+		value, _, _ = l.Field("msg")
+		require.Equal(t, "", value)
+	})
+
+	t.Run("rfc3164", func(t *testing.T) {
+		line := "<34>Oct 11 22:14:15 mymachine su[42]: 'su root' failed for lonvick on /dev/pts/8"
+		l := p.Parse(0, line)
+		require.IsType(t, &StructuredLine{}, l)
+
+		for key, expected := range map[string]string{
+			"facility": "auth",
+			"severity": "crit",
+			"hostname": "mymachine",
+			"appname":  "su",
+			"procid":   "42",
+			"msg":      "'su root' failed for lonvick on /dev/pts/8",
+		} {
+			value, _, ok := l.Field(key)
+			require.True(t, ok, key)
+			require.Equal(t, expected, value, key)
+		}
+	})
+
+	t.Run("without priority", func(t *testing.T) {
+		line := "Oct  1 08:00:01 host CRON[1234]: ERROR pam_unix(cron:session) failed"
+		l := p.Parse(0, line)
+		require.IsType(t, &StructuredLine{}, l)
+
+		value, off, ok := l.Field("level")
+		require.True(t, ok)
+		require.Equal(t, "ERROR", line[off:off+len(value)])
+
+		value, _, _ = l.Field("appname")
+		require.Equal(t, "CRON", value)
+	})
+
+	t.Run("not syslog", func(t *testing.T) {
+		for _, line := range []string{"plain line", "<abc> foo", "Oct 11 22:14:15 host no tag", "<14>1 - host"} {
+			l := p.Parse(0, line)
+			require.IsType(t, &RawLine{}, l, line)
+			require.Equal(t, line, l.String())
+		}
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		f := NewLineFilter()
+		f.Update("level>=error")
+		require.True(t, f.Match(p.Parse(0, "<11>Oct 11 22:14:15 host app: failed")))
+		require.False(t, f.Match(p.Parse(0, "<14>Oct 11 22:14:15 host app: failed")))
+
+		f.Update("appname:sshd AND facility:auth")
+		require.True(t, f.Match(p.Parse(0, "<38>Oct 11 22:14:15 host sshd[1]: accepted")))
+		require.False(t, f.Match(p.Parse(0, "<30>Oct 11 22:14:15 host sshd[1]: accepted")))
+	})
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+var (
+	tableHeaderStyle    = tcell.StyleDefault.Bold(true).Underline(true)
+	tableSeparatorStyle = tcell.StyleDefault.Foreground(tcell.ColorGray)
+)
+
+// separator printed between columns
+const tableColumnSeparator = " │ "
+
+// tableCell is the range of a cell in the line, quotes excluded
+type tableCell struct {
+	start, end int
+}
+
+// TableLine is a CSV or TSV row, the line is kept as is and its cells are
+// exposed as fields named after the header of the table
+type TableLine struct {
+	*StructuredLine
+
+	cells []tableCell
+
+	// header is the header row of the table, nil for the header itself
+	header *TableLine
+}
+
+// Header return the header row of the table of the line
+func (l *TableLine) Header() *TableLine {
+	if l.header == nil {
+		return l
+	}
+	return l.header
+}
+
+// IsHeader return true if the line is the header of its table
+func (l *TableLine) IsHeader() bool {
+	return l.header == nil
+}
+
+// Columns return the number of cells of the line
+func (l *TableLine) Columns() int {
+	return len(l.cells)
+}
+
+// CellWidth return the printed width of the given cell
+func (l *TableLine) CellWidth(col int) int {
+	if col >= len(l.cells) {
+		return 0
+	}
+	c := l.cells[col]
+	return runewidth.StringWidth(l.String()[c.start:c.end])
+}
+
+// PrintColumns print the cells of the line starting at the given column,
+// each cell is padded or truncated to the width of its column
+func (l *TableLine) PrintColumns(p Printer, x, y, width int, widths []int, col int) {
+	bg := tcell.StyleDefault.Background(l.bgcol)
+	for i := col; i < len(widths) && x < width; i++ {
+		if i > col {
+			if x = p.Print(x, y, tableSeparatorStyle.Background(l.bgcol), tableColumnSeparator); x >= width {
+				break
+			}
+		}
+
+		size := widths[i]
+		if x+size > width {
+			size = width - x
+		}
+
+		var filled int
+		if i < len(l.cells) {
+			filled = l.printCell(p, l.cells[i], x, y, size)
+		}
+
+		if filled < size {
+			p.Print(x+filled, y, bg, strings.Repeat(" ", size-filled))
+		}
+
+		x += size
+	}
+
+	fillUpLine(p, x, y, width, bg)
+}
+
+// printCell print the given cell truncated to size columns, with its style
+// and its marks, and return its printed width
+func (l *TableLine) printCell(p Printer, c tableCell, x, y, size int) int {
+	content := l.content.Bytes()
+	end, filled := c.start, 0
+	for end < c.end {
+		r, n := utf8.DecodeRune(content[end:c.end])
+		w := runewidth.RuneWidth(r)
+		if filled+w > size {
+			break
+		}
+		end, filled = end+n, filled+w
+	}
+
+	// column of the given offset of the cell
+	column := func(off int) int {
+		return x + runewidth.StringWidth(string(content[c.start:off]))
+	}
+
+	for _, s := range l.seqs {
+		from, to := s.Index, s.Index+s.Size
+		if from < c.start {
+			from = c.start
+		}
+		if to > end {
+			to = end
+		}
+		if from >= to {
+			continue
+		}
+
+		p.Print(column(from), y, s.Style.Background(l.bgcol), string(content[from:to]))
+	}
+
+	for _, m := range l.marks {
+		from, to := m.Off, m.Off+m.Len
+		if from < c.start {
+			from = c.start
+		}
+		if to > end {
+			to = end
+		}
+		if from >= to {
+			continue
+		}
+
+		style := tcell.StyleDefault.Background(getMarkColor(m.N)).Reverse(true).Bold(true)
+		p.Print(column(from), y, style, string(content[from:to]))
+	}
+
+	return filled
+}
+
+// splitTableRow split a row into cells, cells may be quoted with `"` and
+// quotes escaped by doubling them
+func splitTableRow(line string, sep byte) []tableCell {
+	cells := []tableCell{}
+	for pos := 0; ; {
+		var c tableCell
+		if strings.HasPrefix(line[pos:], `"`) {
+			c.start = pos + 1
+			for pos++; pos < len(line); pos++ {
+				if line[pos] != '"' {
+					continue
+				}
+
+				if pos+1 < len(line) && line[pos+1] == '"' {
+					pos++
+					continue
+				}
+
+				break
+			}
+
+			c.end = pos
+			if pos < len(line) {
+				pos++
+			}
+		} else {
+			c.start = pos
+		}
+
+		next := strings.IndexByte(line[pos:], sep)
+		if next < 0 {
+			next = len(line) - pos
+		}
+
+		// unquoted cell, or garbage after the closing quote
+		if c.end == 0 || next > 0 {
+			c.end = pos + next
+		}
+
+		cells = append(cells, c)
+		if pos += next; pos >= len(line) {
+			return cells
+		}
+		pos++
+	}
+}
+
+// TableParser parse CSV or TSV rows, the first row of each source is its
+// header
+type TableParser struct {
+	// Separator is `,` for CSV and `\t` for TSV
+	Separator byte
+
+	NoColor     bool
+	SourceColor bool
+
+	muHeaders sync.Mutex
+	headers   map[SourceID]*TableLine
+}
+
+func NewTableParser(name string, noColor, sourceColor bool) *TableParser {
+	sep := byte(',')
+	if name == "tsv" {
+		sep = '\t'
+	}
+
+	return &TableParser{
+		Separator:   sep,
+		NoColor:     noColor,
+		SourceColor: sourceColor,
+		headers:     map[SourceID]*TableLine{},
+	}
+}
+
+func (p *TableParser) Parse(sid SourceID, line string) Line {
+	p.muHeaders.Lock()
+	header, ok := p.headers[sid]
+	l := &TableLine{
+		StructuredLine: &StructuredLine{ANSILine: &ANSILine{sid: sid}},
+		cells:          splitTableRow(line, p.Separator),
+		header:         header,
+	}
+	if !ok {
+		p.headers[sid] = l
+	}
+	p.muHeaders.Unlock()
+
+	style := tcell.StyleDefault
+	if !ok && !p.NoColor {
+		style = tableHeaderStyle
+	}
+	l.write(style, line)
+
+	if p.SourceColor {
+		l.bgcol = sid.Color(0.75)
+	}
+
+	if header == nil {
+		return l
+	}
+
+	for i, c := range l.cells {
+		value := line[c.start:c.end]
+		f := &lineField{Key: header.columnName(i), Value: value, off: c.start}
+		if strings.Contains(value, `""`) {
+			f.Value, f.off = strings.ReplaceAll(value, `""`, `"`), -1
+		}
+
+		l.fields = append(l.fields, f)
+	}
+
+	l.parseTime()
+	return l
+}
+
+// columnName return the name of the given column, or `col<n>` for unnamed
+// columns
+func (l *TableLine) columnName(col int) string {
+	if col < len(l.cells) {
+		if c := l.cells[col]; c.end > c.start {
+			return l.String()[c.start:c.end]
+		}
+	}
+
+	return fmt.Sprintf("col%d", col+1)
+}
+
+func isTableParser(name string) bool {
+	return name == "csv" || name == "tsv"
+}
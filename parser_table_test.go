@@ -0,0 +1,142 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitTableRow(t *testing.T) {
+	cases := []struct {
+		Line     string
+		Sep      byte
+		Expected []string
+	}{
+		{"a,b,c", ',', []string{"a", "b", "c"}},
+		{"a,,c,", ',', []string{"a", "", "c", ""}},
+		{`"a,b",c`, ',', []string{"a,b", "c"}},
+		{`"say ""hi""",x`, ',', []string{`say ""hi""`, "x"}},
+		{`"",x`, ',', []string{"", "x"}},
+		{`"unterminated,x`, ',', []string{"unterminated,x"}},
+		{"a b\tc,d", '\t', []string{"a b", "c,d"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Line, func(t *testing.T) {
+			cells := []string{}
+			for _, c := range splitTableRow(tc.Line, tc.Sep) {
+				cells = append(cells, tc.Line[c.start:c.end])
+			}
+			require.Equal(t, tc.Expected, cells)
+		})
+	}
+}
+
+func TestTableParser(t *testing.T) {
+	p := NewTableParser("csv", true, false)
+
+	header := p.Parse(0, "time,level,user name,")
+	require.IsType(t, &TableLine{}, header)
+	require.True(t, header.(*TableLine).IsHeader())
+
+	line := `2022-01-02T15:04:05Z,error,"Doe, ""JD""",extra`
+	l := p.Parse(0, line)
+	require.Equal(t, line, l.String())
+	require.False(t, l.(*TableLine).IsHeader())
+	require.Equal(t, header, l.(*TableLine).Header())
+
+	value, off, ok := l.Field("level")
+	require.True(t, ok)
+	require.Equal(t, "error", line[off:off+len(value)])
+
+	value, off, ok = l.Field("user name")
+	require.True(t, ok)
+	require.Equal(t, `Doe, "JD"`, value)
+	require.Equal(t, -1, off)
+
+	value, _, ok = l.Field("col4")
+	require.True(t, ok)
+	require.Equal(t, "extra", value)
+
+	ts, _, _ := l.Time()
+	require.False(t, ts.IsZero())
+
+	// each source has its own header
+	require.True(t, p.Parse(1, "a,b").(*TableLine).IsHeader())
+
+	f := NewLineFilter()
+	f.Update("level:error")
+	require.True(t, f.Match(l))
+	require.False(t, f.Match(p.Parse(0, "2022-01-02T15:04:05Z,info,x,y")))
+}
+
+type testPrinter struct {
+	lines map[int][]rune
+}
+
+func (p *testPrinter) Print(x, y int, style tcell.Style, str string) int {
+	if p.lines == nil {
+		p.lines = map[int][]rune{}
+	}
+
+	for _, r := range str {
+		for len(p.lines[y]) <= x {
+			p.lines[y] = append(p.lines[y], ' ')
+		}
+		p.lines[y][x] = r
+		x += runewidth.RuneWidth(r)
+	}
+
+	return x
+}
+
+func TestTablePrintColumns(t *testing.T) {
+	p := NewTableParser("csv", true, false)
+	header := p.Parse(0, "id,name,comment").(*TableLine)
+	rows := []fileRow{
+		{line: p.Parse(0, `1,alice,"hello, world"`)},
+		{line: p.Parse(0, "22,bob,")},
+	}
+
+	widths := tableWidths(header, rows)
+	require.Equal(t, []int{2, 5, 12}, widths)
+
+	printer := &testPrinter{}
+	header.PrintColumns(printer, 0, 0, 40, widths, 0)
+	rows[0].line.(*TableLine).PrintColumns(printer, 0, 1, 40, widths, 0)
+	rows[1].line.(*TableLine).PrintColumns(printer, 0, 2, 40, widths, 1)
+
+	require.Equal(t, "id │ name  │ comment", strings.TrimRight(string(printer.lines[0]), " "))
+	require.Equal(t, "1  │ alice │ hello, world", strings.TrimRight(string(printer.lines[1]), " "))
+	require.Equal(t, "bob   │", strings.TrimRight(string(printer.lines[2]), " "))
+
+	// truncated to the width
+	printer = &testPrinter{}
+	rows[0].line.(*TableLine).PrintColumns(printer, 0, 0, 12, widths, 0)
+	require.Equal(t, "1  │ alice │ ", string(printer.lines[0]))
+
+	t.Run("wide runes", func(t *testing.T) {
+		p := NewTableParser("csv", true, false)
+		header := p.Parse(0, "name,city").(*TableLine)
+		rows := []fileRow{
+			{line: p.Parse(0, "José,東京")},
+			{line: p.Parse(0, "Bo,Paris")},
+		}
+
+		widths := tableWidths(header, rows)
+		require.Equal(t, []int{4, 5}, widths)
+
+		// wide runes take two columns, the second one is left blank
+		printer := &testPrinter{}
+		rows[0].line.(*TableLine).PrintColumns(printer, 0, 0, 40, widths, 0)
+		require.Equal(t, "José │ 東 京", strings.TrimRight(string(printer.lines[0]), " "))
+
+		// a wide rune is never cut
+		printer = &testPrinter{}
+		rows[0].line.(*TableLine).PrintColumns(printer, 0, 0, 10, widths, 0)
+		require.Equal(t, "José │ 東  ", string(printer.lines[0]))
+	})
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceParser(t *testing.T) {
+	lcfg := &LoonConfig{
+		Parser:  "ansi",
+		Formats: map[string]*FormatConfig{"nginx": {Name: "nginx"}},
+		Sources: map[string]string{
+			"*.json":          "json",
+			"/var/log/*.json": "logfmt",
+		},
+	}
+
+	t.Run("split argument", func(t *testing.T) {
+		for arg, expected := range map[string][2]string{
+			"api.log":        {"", "api.log"},
+			"json:api.log":   {"json", "api.log"},
+			"nginx:a.log":    {"nginx", "a.log"},
+			"foo:bar.log":    {"", "foo:bar.log"},
+			"logfmt:a:b.log": {"logfmt", "a:b.log"},
+		} {
+			parser, path := splitParserArg(lcfg, arg)
+			require.Equal(t, expected, [2]string{parser, path}, arg)
+		}
+	})
+
+	t.Run("source parser name", func(t *testing.T) {
+		require.Equal(t, "ansi", sourceParserName(lcfg, NewFile("api.log", false)))
+		require.Equal(t, "json", sourceParserName(lcfg, NewFile("logs/api.json", false)))
+		require.Equal(t, "logfmt", sourceParserName(lcfg, NewFile("/var/log/api.json", false)))
+
+		f := NewFile("api.json", false)
+		f.Parser = "raw"
+		require.Equal(t, "raw", sourceParserName(lcfg, f))
+	})
+
+	t.Run("same length patterns", func(t *testing.T) {
+		patterns := map[string]string{
+			"*.json":  "json",
+			"api.js*": "logfmt",
+			"a*.json": "raw",
+			"ap?.*":   "ansi",
+		}
+
+		// map order is random, the lexically first pattern always wins
+		for i := 0; i < 20; i++ {
+			require.Equal(t, "a*.json", sourcePattern(patterns, NewFile("api.json", false)))
+		}
+	})
+
+	t.Run("dispatch", func(t *testing.T) {
+		jsonf, rawf := NewFile("api.json", false), NewFile("api.log", false)
+		rawf.Parser = "raw"
+
+		parser, err := NewSourceParser(lcfg, []File{jsonf, rawf}, false)
+		require.NoError(t, err)
+
+		const line = `{"msg": "foo"}`
+		require.IsType(t, &StructuredLine{}, parser.Parse(jsonf.ID, line))
+		require.IsType(t, &RawLine{}, parser.Parse(rawf.ID, line))
+		require.IsType(t, &ANSILine{}, parser.Parse(0, line))
+	})
+
+	t.Run("unknown parser", func(t *testing.T) {
+		f := NewFile("api.log", false)
+		f.Parser = "unknown"
+		_, err := NewSourceParser(lcfg, []File{f}, false)
+		require.Error(t, err)
+	})
+}
@@ -55,6 +55,24 @@ func (rp *RawPrinter) Print(x, y int, style tcell.Style, str string) int {
 	return emitStr(rp.s, x, y, tcell.StyleDefault, str)
 }
 
+// dimPrinter dim the style of the printed strings
+type dimPrinter struct {
+	Printer
+}
+
+func (dp *dimPrinter) Print(x, y int, style tcell.Style, str string) int {
+	return dp.Printer.Print(x, y, style.Dim(true).Foreground(tcell.ColorGray), str)
+}
+
+// selectedPrinter highlight the background of the printed strings
+type selectedPrinter struct {
+	Printer
+}
+
+func (sp *selectedPrinter) Print(x, y int, style tcell.Style, str string) int {
+	return sp.Printer.Print(x, y, style.Background(tcell.ColorDarkSlateGray), str)
+}
+
 // func emitTruncateStr(s tcell.Screen, x, y int, style tcell.Style, str string) (int, int) {
 // 	sw, sh := s.Size()
 
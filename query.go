@@ -0,0 +1,536 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Query language:
+//
+//	error payment          lines containing `error` or `payment`
+//	error AND payment      lines containing both `error` and `payment`
+//	error -healthcheck     lines containing `error` but not `healthcheck`
+//	NOT healthcheck        lines not containing `healthcheck`
+//	"connection reset"     phrase containing spaces
+//	(error OR warn) AND db grouping
+//	level:error            lines with a `level` field containing `error`
+//	msg:"timeout"          quoted field value
+//	status>=500            numeric comparison on a field
+//	took<1.5s              duration comparison on a field
+//
+// Adjacent terms are OR-ed, unless they are negated: negated terms always
+// exclude lines from the result.
+
+type queryNode interface {
+	// eval the given line, str is the cached value of `l.String()`
+	eval(l Line, str string) (marks []Mark, ok bool)
+}
+
+type queryTerm struct {
+	n      int
+	value  string
+	quoted bool
+
+	// field scope the term to the value of the given field, on lines
+	// without this field the term fallback on matching `raw`
+	field, raw string
+
+	// op is the comparison operator of the term, if any
+	op string
+
+	match, fallback func(s string) []Mark
+	compare         func(value string) bool
+}
+
+func (q *queryTerm) eval(l Line, str string) ([]Mark, bool) {
+	if q.field == "" {
+		marks := q.match(str)
+		return marks, len(marks) > 0
+	}
+
+	value, off, ok := l.Field(q.field)
+	switch {
+	case q.compare != nil:
+		// comparison never fallback on the raw line
+		if !ok || !q.compare(value) {
+			return nil, false
+		}
+
+		if off < 0 {
+			return []Mark{}, true
+		}
+
+		return []Mark{{N: q.n, Off: off, Len: len(value)}}, true
+	case !ok:
+		marks := q.fallback(str)
+		return marks, len(marks) > 0
+	}
+
+	marks := q.match(value)
+	if len(marks) == 0 {
+		return nil, q.matchLevel(value)
+	}
+
+	// place marks on the rendered value
+	if off < 0 {
+		return []Mark{}, true
+	}
+
+	for i := range marks {
+		marks[i].Off += off
+	}
+
+	return marks, true
+}
+
+// matchLevel match the value of level fields on their normalized name, such
+// as the syslog `err` severity for `level:error`
+func (q *queryTerm) matchLevel(value string) bool {
+	if !containsString(structuredLevelKeys, strings.ToLower(q.field)) {
+		return false
+	}
+
+	level, ok := ParseLevel(value)
+	if !ok {
+		return false
+	}
+
+	if term, ok := ParseLevel(q.value); ok && term == level {
+		return true
+	}
+
+	return len(q.match(level.String())) > 0
+}
+
+type queryNot struct {
+	node queryNode
+}
+
+func (q *queryNot) eval(l Line, str string) ([]Mark, bool) {
+	_, ok := q.node.eval(l, str)
+	return nil, !ok
+}
+
+type queryAnd []queryNode
+
+func (q queryAnd) eval(l Line, str string) ([]Mark, bool) {
+	marks := []Mark{}
+	for _, node := range q {
+		m, ok := node.eval(l, str)
+		if !ok {
+			return nil, false
+		}
+		marks = append(marks, m...)
+	}
+
+	return marks, true
+}
+
+type queryOr []queryNode
+
+func (q queryOr) eval(l Line, str string) ([]Mark, bool) {
+	var match bool
+	marks := []Mark{}
+
+	// evaluate every nodes to collect all the marks
+	for _, node := range q {
+		if m, ok := node.eval(l, str); ok {
+			marks = append(marks, m...)
+			match = true
+		}
+	}
+
+	return marks, match
+}
+
+// queryPositiveTerms return the terms of the query which aren't negated
+func queryPositiveTerms(node queryNode) []*queryTerm {
+	switch n := node.(type) {
+	case *queryTerm:
+		return []*queryTerm{n}
+	case queryAnd:
+		return queryPositiveTermsOf(n)
+	case queryOr:
+		return queryPositiveTermsOf(n)
+	default: // negated or empty
+		return nil
+	}
+}
+
+func queryPositiveTermsOf(nodes []queryNode) []*queryTerm {
+	terms := []*queryTerm{}
+	for _, node := range nodes {
+		terms = append(terms, queryPositiveTerms(node)...)
+	}
+	return terms
+}
+
+type queryTokenKind int
+
+const (
+	queryTokenTerm queryTokenKind = iota
+	queryTokenPhrase
+	queryTokenAnd
+	queryTokenOr
+	queryTokenNot
+	queryTokenOpen
+	queryTokenClose
+)
+
+type queryToken struct {
+	kind  queryTokenKind
+	value string
+	field string
+	op    string
+}
+
+func (t queryToken) String() string {
+	switch t.kind {
+	case queryTokenTerm:
+		switch {
+		case t.op != "":
+			return t.field + t.op + t.value
+		case t.field != "":
+			return t.field + ":" + t.value
+		}
+		return t.value
+	case queryTokenPhrase:
+		if t.field != "" {
+			return fmt.Sprintf("%s:%q", t.field, t.value)
+		}
+		return fmt.Sprintf("%q", t.value)
+	case queryTokenOpen:
+		return "("
+	case queryTokenClose:
+		return ")"
+	default:
+		return t.value
+	}
+}
+
+func lexQuery(input string) []queryToken {
+	tokens := []queryToken{}
+	for i := 0; i < len(input); {
+		switch c := input[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '"':
+			phrase, size := lexQueryPhrase(input[i:])
+			tokens = append(tokens, queryToken{kind: queryTokenPhrase, value: phrase})
+			i += size
+		case c == '-' && i+1 < len(input) && input[i+1] != ' ':
+			tokens = append(tokens, queryToken{kind: queryTokenNot, value: "-"})
+			i++
+		default:
+			end := strings.IndexAny(input[i:], " \t\"")
+			if end < 0 {
+				end = len(input) - i
+			}
+
+			word := input[i : i+end]
+			i += end
+
+			// quoted field value: `key:"some value"`
+			if key, ok := queryFieldKey(word); ok && i < len(input) && input[i] == '"' {
+				phrase, size := lexQueryPhrase(input[i:])
+				tokens = append(tokens, queryToken{kind: queryTokenPhrase, value: phrase, field: key})
+				i += size
+				continue
+			}
+
+			tokens = append(tokens, lexQueryWord(word)...)
+		}
+	}
+
+	return tokens
+}
+
+// lexQueryPhrase read a quoted phrase, an unterminated phrase end with the
+// input. Only `\"` and `\\` are unescaped so patterns can be quoted as is.
+func lexQueryPhrase(input string) (phrase string, size int) {
+	var sb strings.Builder
+	for size = 1; size < len(input); size++ {
+		switch c := input[size]; {
+		case c == '\\' && size+1 < len(input) && (input[size+1] == '"' || input[size+1] == '\\'):
+			size++
+			sb.WriteByte(input[size])
+		case c == '"':
+			return sb.String(), size + 1
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return sb.String(), size
+}
+
+// lexQueryWord split unbalanced parentheses from the given word, so
+// `(error` or `warn)` are grouping but `(\w+)=(\d+)` is a single term
+func lexQueryWord(word string) []queryToken {
+	switch word {
+	case "AND", "&&":
+		return []queryToken{{kind: queryTokenAnd, value: word}}
+	case "OR", "||":
+		return []queryToken{{kind: queryTokenOr, value: word}}
+	case "NOT":
+		return []queryToken{{kind: queryTokenNot, value: word}}
+	}
+
+	var depth, opened int
+	for i := 0; i < len(word); i++ {
+		switch word[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			} else {
+				opened++
+			}
+		}
+	}
+
+	prefix, suffix := []queryToken{}, []queryToken{}
+	for ; depth > 0 && len(word) > 0 && word[0] == '('; depth-- {
+		prefix = append(prefix, queryToken{kind: queryTokenOpen})
+		word = word[1:]
+	}
+
+	for ; opened > 0 && len(word) > 0 && word[len(word)-1] == ')'; opened-- {
+		suffix = append(suffix, queryToken{kind: queryTokenClose})
+		word = word[:len(word)-1]
+	}
+
+	tokens := prefix
+	if word != "" {
+		tok := queryToken{kind: queryTokenTerm, value: word}
+		if i := strings.IndexAny(word, ":<>"); i > 0 {
+			op := word[i : i+1]
+			if op != ":" && i+1 < len(word) && word[i+1] == '=' {
+				op += "="
+			}
+
+			// comparisons need a comparable value, `foo->bar` or `a>b`
+			// are text terms
+			key, ok := queryFieldKey(word[:i] + ":")
+			value := word[i+len(op):]
+			if op != ":" {
+				_, _, comparable := parseComparable(value)
+				ok = ok && comparable
+			}
+
+			if ok && value != "" {
+				tok.field, tok.value = key, value
+				if op != ":" {
+					tok.op = op
+				}
+			}
+		}
+
+		tokens = append(tokens, tok)
+	}
+
+	return append(tokens, suffix...)
+}
+
+// queryFieldKey check if the given word is a field prefix such as
+// `level:` or `user.id:` and return the field key
+func queryFieldKey(word string) (string, bool) {
+	key := strings.TrimSuffix(word, ":")
+	if key == word || key == "" {
+		return "", false
+	}
+
+	for i, c := range key {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_', c == '@':
+		case i > 0 && (c >= '0' && c <= '9' || c == '.' || c == '-'):
+		default:
+			return "", false
+		}
+	}
+
+	return key, true
+}
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+	terms  []*queryTerm
+}
+
+// parseQuery parse the given input into a query tree, a nil node is
+// returned on empty query. Incomplete query such as unclosed
+// parentheses or trailing operators are tolerated since the query is
+// parsed while being typed.
+func parseQuery(input string) (queryNode, []*queryTerm, error) {
+	p := &queryParser{tokens: lexQuery(input)}
+	if len(p.tokens) == 0 {
+		return nil, nil, nil
+	}
+
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if tok, ok := p.peek(); ok {
+		return nil, nil, fmt.Errorf("unexpected `%s`", tok)
+	}
+
+	return node, p.terms, nil
+}
+
+func (p *queryParser) peek() (tok queryToken, ok bool) {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos], true
+	}
+	return
+}
+
+func (p *queryParser) next() (tok queryToken, ok bool) {
+	if tok, ok = p.peek(); ok {
+		p.pos++
+	}
+	return
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	type operand struct {
+		node     queryNode
+		explicit bool // joined with an explicit `OR`
+	}
+
+	operands := []operand{}
+	explicit := false
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind == queryTokenClose {
+			break
+		}
+
+		if tok.kind == queryTokenOr {
+			p.next()
+			explicit = true
+			continue
+		}
+
+		node, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		if node != nil {
+			operands = append(operands, operand{node, explicit})
+		}
+		explicit = false
+	}
+
+	// negated operands which are not part of an explicit `OR` are
+	// exclusions
+	or, exclusions := queryOr{}, queryAnd{}
+	for i, op := range operands {
+		_, not := op.node.(*queryNot)
+		if not && !op.explicit && (i+1 >= len(operands) || !operands[i+1].explicit) {
+			exclusions = append(exclusions, op.node)
+		} else {
+			or = append(or, op.node)
+		}
+	}
+
+	var node queryNode
+	switch len(or) {
+	case 0:
+	case 1:
+		node = or[0]
+	default:
+		node = or
+	}
+
+	if len(exclusions) == 0 {
+		return node, nil
+	}
+
+	if node != nil {
+		return append(queryAnd{node}, exclusions...), nil
+	}
+
+	return exclusions, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	node, err := p.parseUnary()
+	if err != nil || node == nil {
+		return node, err
+	}
+
+	and := queryAnd{node}
+	for {
+		if tok, ok := p.peek(); !ok || tok.kind != queryTokenAnd {
+			break
+		}
+		p.next()
+
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		if node != nil {
+			and = append(and, node)
+		}
+	}
+
+	if len(and) == 1 {
+		return and[0], nil
+	}
+
+	return and, nil
+}
+
+func (p *queryParser) parseUnary() (queryNode, error) {
+	tok, ok := p.peek()
+	if !ok || tok.kind == queryTokenClose {
+		return nil, nil
+	}
+	p.next()
+
+	switch tok.kind {
+	case queryTokenNot:
+		node, err := p.parseUnary()
+		if err != nil || node == nil {
+			return nil, err
+		}
+		return &queryNot{node}, nil
+	case queryTokenOpen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		// unclosed parenthesis are closed at the end of the input
+		if tok, ok := p.peek(); ok && tok.kind == queryTokenClose {
+			p.next()
+		}
+
+		return node, nil
+	case queryTokenTerm, queryTokenPhrase:
+		term := &queryTerm{
+			n:      len(p.terms),
+			value:  tok.value,
+			quoted: tok.kind == queryTokenPhrase,
+			field:  tok.field,
+			op:     tok.op,
+		}
+
+		if tok.field != "" && tok.op == "" {
+			term.raw = tok.field + ":" + tok.value
+		}
+		p.terms = append(p.terms, term)
+		return term, nil
+	default: // dangling operator, ignore it
+		return p.parseUnary()
+	}
+}
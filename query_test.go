@@ -0,0 +1,165 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryLexer(t *testing.T) {
+	cases := []struct {
+		Input    string
+		Expected []string
+	}{
+		{"", []string{}},
+		{"foo bar", []string{"foo", "bar"}},
+		{`"foo bar" baz`, []string{`"foo bar"`, "baz"}},
+		{`"foo \"bar\""`, []string{`"foo \"bar\""`}},
+		{`"unterminated`, []string{`"unterminated"`}},
+		{"-foo", []string{"-", "foo"}},
+		{"foo - bar", []string{"foo", "-", "bar"}},
+		{"(foo OR bar) AND NOT baz", []string{"(", "foo", "OR", "bar", ")", "AND", "NOT", "baz"}},
+		{`(\w+)=(\d+)`, []string{`(\w+)=(\d+)`}},
+		{`((\w+)`, []string{"(", `(\w+)`}},
+		{`f\(oo`, []string{`f\(oo`}},
+		{"level:error", []string{"level:error"}},
+		{`msg:"timed out" x`, []string{`msg:"timed out"`, "x"}},
+		{"http://foo 12:30 :foo foo:", []string{"http://foo", "12:30", ":foo", "foo:"}},
+		{"status>=500 took<1.5s a>b x> <y", []string{"status>=500", "took<1.5s", "a>b", "x>", "<y"}},
+		{"-level:debug (user.id:42 OR x)", []string{"-", "level:debug", "(", "user.id:42", "OR", "x", ")"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			tokens := []string{}
+			for _, tok := range lexQuery(tc.Input) {
+				tokens = append(tokens, tok.String())
+			}
+			require.Equal(t, tc.Expected, tokens)
+		})
+	}
+}
+
+func TestFilterQuery(t *testing.T) {
+	f := NewLineFilter()
+	testFilterCases(t, f, []testFilterCase{
+		{"or", "error payment", "payment ok", true, []Mark{{1, 0, 7}}},
+		{"and", "error AND payment", "payment ok", false, nil},
+		{"and match", "error AND payment", "payment error", true, []Mark{{0, 8, 5}, {1, 0, 7}}},
+		{"and not", "error AND payment AND NOT healthcheck", "error payment healthcheck", false, nil},
+		{"exclude", "error -healthcheck", "error healthcheck", false, nil},
+		{"exclude match", "error -healthcheck", "error payment", true, []Mark{{0, 0, 5}}},
+		{"exclude only", "-healthcheck", "error payment", true, nil},
+		{"explicit or not", "error OR -healthcheck", "payment", true, nil},
+		{"phrase", `"connection reset"`, "tcp connection reset", true, []Mark{{0, 4, 16}}},
+		{"phrase no match", `"connection reset"`, "connection was reset", false, nil},
+		{"group", "(error OR warn) AND db", "warn: db is slow", true, []Mark{{1, 0, 4}, {2, 6, 2}}},
+		{"group no match", "(error OR warn) AND db", "warn: cache is slow", false, nil},
+		{"unclosed group", "(error OR warn", "warn", true, []Mark{{1, 0, 4}}},
+		{"dangling operator", "error AND", "error", true, []Mark{{0, 0, 5}}},
+	})
+
+	t.Run("unexpected parenthesis", func(t *testing.T) {
+		f.Update("error)")
+		require.Error(t, f.Err())
+		f.Update(`"error)"`)
+		require.NoError(t, f.Err())
+		f.Update("error )")
+		require.Error(t, f.Err())
+	})
+}
+
+func TestFilterField(t *testing.T) {
+	parser := &JSONParser{NoColor: true, Fallback: &ANSIParser{NoColor: true}}
+	line := `{"level": "error", "msg": "request timeout", "user": {"id": 42}, "err": "timeout reached"}`
+
+	cases := []struct {
+		Name, Input, Line string
+		Match             bool
+		Marks             []Mark
+	}{
+		// rendered: `ERROR request timeout user.id=42 err="timeout reached"`
+		{"field", "level:error", line, true, []Mark{{0, 0, 5}}},
+		{"field no match", "level:info", line, false, nil},
+		{"nested field", "user.id:42", line, true, []Mark{{0, 30, 2}}},
+		{"alias", "message:timeout", line, true, []Mark{{0, 14, 7}}},
+		{"quoted value", `err:"timeout reached"`, line, true, []Mark{{0, 38, 15}}},
+		{"scoped", "user.id:timeout", line, false, nil},
+		{"missing field", "status:500", line, false, nil},
+		{"raw fallback", "status:500", "GET / status:500", true, []Mark{{0, 6, 10}}},
+	}
+
+	f := NewLineFilter()
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			f.Update(tc.Input)
+			require.NoError(t, f.Err())
+
+			l := parser.Parse(0, tc.Line)
+			require.Equal(t, tc.Match, f.Match(l))
+			if tc.Match {
+				require.Equal(t, tc.Marks, testLineMarks(l))
+			}
+		})
+	}
+}
+
+func TestFilterCompare(t *testing.T) {
+	parser := &JSONParser{NoColor: true, Fallback: &ANSIParser{NoColor: true}}
+	line := `{"msg": "done", "status": 503, "took": "1.6s", "bytes": "512", "path": "/"}`
+
+	cases := []struct {
+		Name, Input string
+		Match       bool
+	}{
+		{"greater", "status>500", true},
+		{"greater or equal", "status>=503", true},
+		{"lower", "status<503", false},
+		{"lower or equal", "status<=503", true},
+		{"string number", "bytes<1024", true},
+		{"duration", "took>1.5s", true},
+		{"duration unit", "took<2000ms", true},
+		{"duration no match", "took<1s", false},
+		{"kind mismatch", "took>1", false},
+		{"not numeric", "path>1", false},
+		{"missing field", "duration_ms>500", false},
+		{"combined", "status>=500 AND took>1s", true},
+	}
+
+	f := NewLineFilter()
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			f.Update(tc.Input)
+			require.NoError(t, f.Err())
+			require.Equal(t, tc.Match, f.Match(parser.Parse(0, line)))
+		})
+	}
+
+	t.Run("marks", func(t *testing.T) {
+		// rendered: `done status=503 took=1.6s bytes=512 path=/`
+		f.Update("status>500")
+		l := parser.Parse(0, line)
+		require.True(t, f.Match(l))
+		require.Equal(t, []Mark{{0, 12, 3}}, testLineMarks(l))
+	})
+
+	t.Run("not comparable", func(t *testing.T) {
+		for input, match := range map[string]bool{
+			"status>foo": false,
+			"msg->done":  false,
+			"<nil>":      false,
+			"path>/":     false,
+		} {
+			f.Update(input)
+			require.NoError(t, f.Err(), input)
+			require.Equal(t, match, f.Match(parser.Parse(0, line)), input)
+		}
+
+		raw := &ANSIParser{NoColor: true}
+		for _, input := range []string{"foo->bar", "<nil>", "a>b"} {
+			f.Update(input)
+			require.NoError(t, f.Err(), input)
+			require.True(t, f.Match(raw.Parse(0, "x foo->bar <nil> a>b")), input)
+		}
+	})
+}
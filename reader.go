@@ -1,11 +1,17 @@
 package main
 
 import (
+	"bufio"
+	"container/heap"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/nxadm/tail"
@@ -32,6 +38,9 @@ type File struct {
 	ID    SourceID
 	Path  string
 	Stdin bool
+
+	// Parser is the parser name of this source, empty for the default one
+	Parser string
 }
 
 func NewFile(path string, stdin bool) (f File) {
@@ -43,6 +52,14 @@ func NewFile(path string, stdin bool) (f File) {
 
 func NewReader(lcfg *LoonConfig, f File) (Reader, error) {
 	size := lcfg.RingSize
+	format := sourceParserName(lcfg, f)
+
+	// container logs entries are split in chunks of 16KiB by the runtime,
+	// they are reassembled as is, up to maxPartialSize
+	linesize := lcfg.LineSize
+	if isContainerParser(format) {
+		linesize = 0
+	}
 
 	var cursor int64
 	if !f.Stdin {
@@ -54,23 +71,104 @@ func NewReader(lcfg *LoonConfig, f File) (Reader, error) {
 		}
 	}
 
-	tail, err := tailFile(lcfg, cursor, f)
+	// history is loaded upfront to be merged with other sources
+	var history []string
+	if lcfg.Merge && !f.Stdin {
+		var err error
+		if history, cursor, err = readHistory(f.Path, cursor, linesize); err != nil {
+			return nil, fmt.Errorf("unable to read history: %w", err)
+		}
+	}
+
+	// the header of a table is the first line of the file
+	if isTableParser(format) && cursor > 0 {
+		header, err := readFirstLine(f.Path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read table header: %w", err)
+		}
+		history = append([]string{header}, history...)
+	}
+
+	tail, err := tailFile(cursor, f, linesize)
 	if err != nil {
 		return nil, fmt.Errorf("unable to tail file: %w", err)
 	}
 
-	return &TailReader{
-		lines: 0,
-		file:  f,
-		tail:  tail,
-	}, nil
+	reader := &TailReader{
+		lines:   0,
+		file:    f,
+		tail:    tail,
+		history: history,
+	}
+
+	// container logs entries may be split
+	if isContainerParser(format) {
+		return NewPartialReader(reader, format), nil
+	}
+
+	return reader, nil
 }
 
-func tailFile(lcfg *LoonConfig, cursor int64, f File) (*tail.Tail, error) {
+// readHistory read lines from the given position to the end of the file,
+// and return the position of the last complete line, lines longer than
+// linesize are split if it isn't zero
+func readHistory(path string, cursor int64, linesize int) ([]string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, cursor, nil
+		}
+		return nil, cursor, fmt.Errorf("cannot open file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(cursor, io.SeekStart); err != nil {
+		return nil, cursor, fmt.Errorf("cannot seek file: %w", err)
+	}
+
+	lines := []string{}
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			// an incomplete line is left to the tail
+			return lines, cursor, nil
+		}
+
+		cursor += int64(len(line))
+		line = strings.TrimRight(line, "\r\n")
+
+		// split long lines as tail does
+		for linesize > 0 && len(line) > linesize {
+			lines = append(lines, line[:linesize])
+			line = line[linesize:]
+		}
+
+		lines = append(lines, line)
+	}
+}
+
+// readFirstLine read the first line of the given file
+func readFirstLine(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot open file: %w", err)
+	}
+	defer file.Close()
+
+	line, err := bufio.NewReader(file).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("cannot read file: %w", err)
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func tailFile(cursor int64, f File, linesize int) (*tail.Tail, error) {
 	config := tail.Config{
 		ReOpen:      true,
 		Follow:      true,
-		MaxLineSize: lcfg.LineSize,
+		MaxLineSize: linesize,
 		Logger:      tail.DiscardingLogger,
 		Pipe:        f.Stdin,
 	}
@@ -124,10 +222,20 @@ func getPostionFromBottom(path string, lines int64) (int64, error) {
 
 }
 
+// HistoryReader is a reader which loaded its history upfront
+type HistoryReader interface {
+	Reader
+
+	// History return the lines which haven't been read yet from the
+	// history, they won't be returned by Readline
+	History() []string
+}
+
 type TailReader struct {
-	tail  *tail.Tail
-	lines int
-	file  File
+	tail    *tail.Tail
+	lines   int
+	file    File
+	history []string
 
 	muLines sync.RWMutex
 }
@@ -152,8 +260,26 @@ func (f *TailReader) ResetLines() {
 	f.muLines.Unlock()
 }
 
+func (f *TailReader) History() (history []string) {
+	f.muLines.Lock()
+	history, f.history = f.history, nil
+	f.lines += len(history)
+	f.muLines.Unlock()
+	return
+}
+
 func (f *TailReader) Readline() (s string, sid SourceID, err error) {
 	sid = f.file.ID
+
+	f.muLines.Lock()
+	if len(f.history) > 0 {
+		s, f.history = f.history[0], f.history[1:]
+		f.lines++
+		f.muLines.Unlock()
+		return
+	}
+	f.muLines.Unlock()
+
 	line := <-f.tail.Lines
 	if line != nil {
 		f.muLines.Lock()
@@ -183,9 +309,40 @@ type MultiReader struct {
 	readers  []Reader
 	sources  []File
 	cline    chan *multiReaderSource
+	history  []*multiReaderSource
 	muReader sync.RWMutex
 }
 
+// NewMergeReader create a multi reader which return the history of the
+// readers ordered by the timestamp of their parsed lines, before
+// interleaving live lines. The parser shouldn't be shared since parsers may
+// keep a state per source
+func NewMergeReader(parser Parser[Line], readers ...Reader) *MultiReader {
+	histories := make([][]*multiReaderSource, len(readers))
+	for i, reader := range readers {
+		hreader, ok := reader.(HistoryReader)
+		if !ok {
+			continue
+		}
+
+		sid := SourceID(0)
+		if sources := reader.Sources(); len(sources) > 0 {
+			sid = sources[0].ID
+		}
+
+		for _, line := range hreader.History() {
+			histories[i] = append(histories[i], &multiReaderSource{sid: sid, line: line})
+		}
+	}
+
+	m := NewMultiReader(readers...)
+	m.history = mergeHistories(histories, func(s *multiReaderSource) time.Time {
+		t, _, _ := parser.Parse(s.sid, s.line).Time()
+		return t
+	})
+	return m
+}
+
 func NewMultiReader(readers ...Reader) *MultiReader {
 	wg := sync.WaitGroup{}
 	cline := make(chan *multiReaderSource)
@@ -251,6 +408,15 @@ func (m *MultiReader) ResetLines() {
 }
 
 func (m *MultiReader) Readline() (s string, sid SourceID, err error) {
+	m.muReader.Lock()
+	if len(m.history) > 0 {
+		source := m.history[0]
+		m.history = m.history[1:]
+		m.muReader.Unlock()
+		return source.line, source.sid, nil
+	}
+	m.muReader.Unlock()
+
 	if source := <-m.cline; source != nil {
 		s, sid, err = source.line, source.sid, source.err
 		return
@@ -259,3 +425,71 @@ func (m *MultiReader) Readline() (s string, sid SourceID, err error) {
 	err = fmt.Errorf("no more reader to read")
 	return
 }
+
+type historyCursor struct {
+	index int // index of the history, used to keep ties stable
+	lines []*multiReaderSource
+	times []time.Time
+}
+
+type historyHeap []*historyCursor
+
+func (h historyHeap) Len() int      { return len(h) }
+func (h historyHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h historyHeap) Less(i, j int) bool {
+	if ti, tj := h[i].times[0], h[j].times[0]; !ti.Equal(tj) {
+		return ti.Before(tj)
+	}
+	return h[i].index < h[j].index
+}
+
+func (h *historyHeap) Push(x any) { *h = append(*h, x.(*historyCursor)) }
+func (h *historyHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// mergeHistories merge the given histories by the timestamp returned by
+// timeOf, each history being ordered already. Lines without timestamp keep
+// the timestamp of the previous line of their history, so multiline records
+// stay together.
+func mergeHistories(histories [][]*multiReaderSource, timeOf func(s *multiReaderSource) time.Time) []*multiReaderSource {
+	h := historyHeap{}
+	var size int
+	for i, lines := range histories {
+		if len(lines) == 0 {
+			continue
+		}
+
+		times := make([]time.Time, len(lines))
+		var last time.Time
+		for j, l := range lines {
+			if t := timeOf(l); !t.IsZero() {
+				last = t
+			}
+			times[j] = last
+		}
+
+		h = append(h, &historyCursor{index: i, lines: lines, times: times})
+		size += len(lines)
+	}
+
+	heap.Init(&h)
+
+	merged := make([]*multiReaderSource, 0, size)
+	for h.Len() > 0 {
+		cursor := h[0]
+		merged = append(merged, cursor.lines[0])
+
+		cursor.lines, cursor.times = cursor.lines[1:], cursor.times[1:]
+		if len(cursor.lines) == 0 {
+			heap.Pop(&h)
+		} else {
+			heap.Fix(&h, 0)
+		}
+	}
+
+	return merged
+}
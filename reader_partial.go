@@ -0,0 +1,112 @@
+package main
+
+import (
+	"strings"
+	"sync"
+)
+
+// reassembled messages are flushed when they reach this size
+const maxPartialSize = 1 << 20
+
+type partialKey struct {
+	sid    SourceID
+	stream string
+}
+
+type partialEntry struct {
+	first   containerEntry
+	message strings.Builder
+}
+
+// PartialReader reassemble container log entries split into partial
+// entries, so whole lines are returned
+type PartialReader struct {
+	Reader
+
+	// format is either `docker` or `cri`
+	format string
+
+	muPartial sync.Mutex
+	partial   map[partialKey]*partialEntry
+}
+
+func NewPartialReader(reader Reader, format string) *PartialReader {
+	return &PartialReader{
+		Reader:  reader,
+		format:  format,
+		partial: map[partialKey]*partialEntry{},
+	}
+}
+
+func (r *PartialReader) Readline() (string, SourceID, error) {
+	for {
+		line, sid, err := r.Reader.Readline()
+		if err != nil {
+			return line, sid, err
+		}
+
+		if line, ok := r.reassemble(sid, line); ok {
+			return line, sid, nil
+		}
+	}
+}
+
+// History reassemble the history of the underlying reader
+func (r *PartialReader) History() []string {
+	hreader, ok := r.Reader.(HistoryReader)
+	if !ok {
+		return nil
+	}
+
+	var sid SourceID
+	if sources := r.Sources(); len(sources) > 0 {
+		sid = sources[0].ID
+	}
+
+	history := hreader.History()
+	lines := make([]string, 0, len(history))
+	for _, line := range history {
+		if line, ok := r.reassemble(sid, line); ok {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// reassemble keep partial entries until the final entry of their stream,
+// lines which aren't container log entries are returned as is
+func (r *PartialReader) reassemble(sid SourceID, line string) (string, bool) {
+	e, ok := parseContainerEntry(r.format, line)
+	if !ok {
+		return line, true
+	}
+
+	r.muPartial.Lock()
+	defer r.muPartial.Unlock()
+
+	key := partialKey{sid: sid, stream: e.Stream}
+	pending, ok := r.partial[key]
+	switch {
+	case !ok && !e.Partial:
+		return line, true
+	case !ok:
+		pending = &partialEntry{first: e}
+		r.partial[key] = pending
+	}
+
+	pending.message.WriteString(e.Message)
+	if e.Partial && pending.message.Len() < maxPartialSize {
+		return "", false
+	}
+
+	delete(r.partial, key)
+
+	whole := pending.first
+	whole.Message = pending.message.String()
+	if r.format == "docker" {
+		return whole.dockerLine(), true
+	}
+
+	return whole.criLine(), true
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeHistories(t *testing.T) {
+	history := func(sid SourceID, lines ...string) []*multiReaderSource {
+		sources := make([]*multiReaderSource, len(lines))
+		for i, line := range lines {
+			sources[i] = &multiReaderSource{sid: sid, line: line}
+		}
+		return sources
+	}
+
+	merged := mergeHistories([][]*multiReaderSource{
+		history(1,
+			"2022-01-02T15:04:01Z a started",
+			"2022-01-02T15:04:03Z a failed",
+			"    at main.go:42",
+			"2022-01-02T15:04:06Z a stopped",
+		),
+		nil,
+		history(2,
+			"2022-01-02T15:04:02Z b started",
+			"2022-01-02T15:04:03Z b failed",
+			"2022-01-02T15:04:05Z b stopped",
+		),
+	}, func(s *multiReaderSource) time.Time {
+		t, _, _ := DetectTime(s.line)
+		return t
+	})
+
+	lines := make([]string, len(merged))
+	for i, m := range merged {
+		lines[i] = m.line
+	}
+
+	require.Equal(t, []string{
+		"2022-01-02T15:04:01Z a started",
+		"2022-01-02T15:04:02Z b started",
+		"2022-01-02T15:04:03Z a failed",
+		"    at main.go:42",
+		"2022-01-02T15:04:03Z b failed",
+		"2022-01-02T15:04:05Z b stopped",
+		"2022-01-02T15:04:06Z a stopped",
+	}, lines)
+}
+
+func TestMergeReader(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, lines ...string) File {
+		path := filepath.Join(dir, name)
+		err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600)
+		require.NoError(t, err)
+		return NewFile(path, false)
+	}
+
+	lcfg := &LoonConfig{RingSize: 100, LineSize: 1000, Merge: true}
+	files := []File{
+		write("a.log", "2022/01/02 15:04:01 a1", "2022/01/02 15:04:04 a2"),
+		write("b.log", "2022/01/02 15:04:02 b1", "2022/01/02 15:04:03 b2"),
+	}
+
+	readers := []Reader{}
+	for _, f := range files {
+		reader, err := NewReader(lcfg, f)
+		require.NoError(t, err)
+		readers = append(readers, reader)
+	}
+
+	m := NewMergeReader(&RawParser{}, readers...)
+	expected := []struct {
+		line string
+		sid  SourceID
+	}{
+		{"2022/01/02 15:04:01 a1", files[0].ID},
+		{"2022/01/02 15:04:02 b1", files[1].ID},
+		{"2022/01/02 15:04:03 b2", files[1].ID},
+		{"2022/01/02 15:04:04 a2", files[0].ID},
+	}
+
+	for _, e := range expected {
+		line, sid, err := m.Readline()
+		require.NoError(t, err)
+		require.Equal(t, e.line, line)
+		require.Equal(t, e.sid, sid)
+	}
+
+	require.Equal(t, 4, m.Lines())
+}
+
+func TestMergeReaderParsedTime(t *testing.T) {
+	dir := t.TempDir()
+	lcfg := &LoonConfig{RingSize: 100, LineSize: 1000, Merge: true}
+
+	files := []File{}
+	readers := []Reader{}
+	for name, lines := range map[string][]string{
+		"a.log": {`{"ts": 1641135841, "msg": "a1"}`, `{"ts": 1641135844, "msg": "a2"}`},
+		"b.log": {`{"ts": 1641135842, "msg": "b1"}`, `{"ts": 1641135843, "msg": "b2"}`},
+	} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600))
+
+		f := NewFile(path, false)
+		reader, err := NewReader(lcfg, f)
+		require.NoError(t, err)
+		files, readers = append(files, f), append(readers, reader)
+	}
+
+	// the raw lines have no timestamp
+	parser := &JSONParser{NoColor: true, Fallback: &RawParser{}}
+	m := NewMergeReader(parser, readers...)
+	for _, expected := range []string{"a1", "b1", "b2", "a2"} {
+		line, _, err := m.Readline()
+		require.NoError(t, err)
+		require.Contains(t, line, `"`+expected+`"`)
+	}
+}
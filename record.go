@@ -0,0 +1,179 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// maximum number of lines folded into a record
+const maxRecordLines = 1000
+
+// MultiLine is implemented by lines rendered on several rows
+type MultiLine interface {
+	Line
+
+	// Lines return the lines of the entry, the first one being the entry
+	// itself when collapsed
+	Lines() []Line
+}
+
+// Record is a logical entry made of a first line followed by its
+// continuation lines, such as a stack trace. It's matched as a whole, its
+// content being the lines joined by a new line.
+type Record struct {
+	muRecord sync.RWMutex
+
+	lines   []Line
+	offs    []int // offset of each line in the content
+	content strings.Builder
+	width   int
+
+	// goroutine is parsed lazily when the record is a goroutine block
+	goroutine       *Goroutine
+	goroutineParsed bool
+}
+
+func NewRecord(first Line) *Record {
+	r := &Record{}
+	r.Append(first)
+	return r
+}
+
+// Append add a continuation line to the record
+func (r *Record) Append(l Line) {
+	r.muRecord.Lock()
+	if len(r.lines) > 0 {
+		r.content.WriteByte('\n')
+	}
+
+	r.offs = append(r.offs, r.content.Len())
+	r.lines = append(r.lines, l)
+	r.content.WriteString(l.String())
+	if size := l.Len(); size > r.width {
+		r.width = size
+	}
+	r.goroutine, r.goroutineParsed = nil, false
+	r.muRecord.Unlock()
+}
+
+// Replace replace the line at the given index
+func (r *Record) Replace(i int, l Line) {
+	r.muRecord.Lock()
+	r.lines[i] = l
+
+	r.content.Reset()
+	r.width = 0
+	for j, l := range r.lines {
+		if j > 0 {
+			r.content.WriteByte('\n')
+		}
+
+		r.offs[j] = r.content.Len()
+		r.content.WriteString(l.String())
+		if size := l.Len(); size > r.width {
+			r.width = size
+		}
+	}
+
+	r.goroutine, r.goroutineParsed = nil, false
+	r.muRecord.Unlock()
+}
+
+func (r *Record) Lines() (lines []Line) {
+	r.muRecord.RLock()
+	lines = make([]Line, len(r.lines))
+	copy(lines, r.lines)
+	r.muRecord.RUnlock()
+	return
+}
+
+func (r *Record) first() (l Line) {
+	r.muRecord.RLock()
+	l = r.lines[0]
+	r.muRecord.RUnlock()
+	return
+}
+
+// SetMarks dispatch marks on the lines of the record, marks spanning
+// several lines are split
+func (r *Record) SetMarks(marks ...Mark) {
+	r.muRecord.RLock()
+	defer r.muRecord.RUnlock()
+
+	lmarks := make([][]Mark, len(r.lines))
+	for _, m := range marks {
+		for i, off := range r.offs {
+			from, to := m.Off-off, m.Off+m.Len-off
+			size := r.lines[i].Len()
+			if to <= 0 || from >= size {
+				continue
+			}
+
+			if from < 0 {
+				from = 0
+			}
+
+			if to > size {
+				to = size
+			}
+
+			lmarks[i] = append(lmarks[i], Mark{N: m.N, Off: from, Len: to - from})
+		}
+	}
+
+	for i, l := range r.lines {
+		l.SetMarks(lmarks[i]...)
+	}
+}
+
+// Print only print the first line of the record
+func (r *Record) Print(p Printer, x, y, width, offset int) {
+	r.first().Print(p, x, y, width, offset)
+}
+
+func (r *Record) String() (s string) {
+	r.muRecord.RLock()
+	s = r.content.String()
+	r.muRecord.RUnlock()
+	return
+}
+
+// Len return the size of the longest line
+func (r *Record) Len() (size int) {
+	r.muRecord.RLock()
+	size = r.width
+	r.muRecord.RUnlock()
+	return
+}
+
+func (r *Record) Source() SourceID {
+	return r.first().Source()
+}
+
+// Goroutine return the goroutine of the record if it's a goroutine block
+func (r *Record) Goroutine() (*Goroutine, bool) {
+	r.muRecord.Lock()
+	defer r.muRecord.Unlock()
+
+	if !r.goroutineParsed {
+		r.goroutine, _ = parseGoroutine(r.lines, r.offs)
+		r.goroutineParsed = true
+	}
+
+	return r.goroutine, r.goroutine != nil
+}
+
+func (r *Record) Field(key string) (string, int, bool) {
+	if g, ok := r.Goroutine(); ok {
+		if value, off, ok := g.Field(key); ok {
+			return value, off, true
+		}
+	}
+
+	return r.first().Field(key)
+}
+
+func (r *Record) Time() (time.Time, int, int) {
+	return r.first().Time()
+}
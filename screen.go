@@ -1,9 +1,12 @@
 package main
 
 import (
+	"container/ring"
 	"fmt"
-	"strings"
+	"sort"
 	"sync"
+	"time"
+	"unicode"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/gdamore/tcell/v2/encoding"
@@ -20,6 +23,14 @@ type Line interface {
 	String() string
 	Len() int
 	Source() SourceID
+
+	// Field return the value of the given field and its offset in the
+	// rendered line, or -1 if the value isn't rendered as is
+	Field(key string) (value string, off int, ok bool)
+
+	// Time return the timestamp of the line and its offset and size in the
+	// rendered line, a zero time if the line has no timestamp
+	Time() (t time.Time, off, size int)
 }
 
 type BufferWindowLine = BufferWindow[Line]
@@ -27,14 +38,23 @@ type BufferWindowLine = BufferWindow[Line]
 type Screen struct {
 	muScreen sync.RWMutex
 
+	lcfg    *LoonConfig
+	sources []File
+
 	ts      tcell.Screen
 	cupdate chan struct{}
 
 	bufferw *BufferWindowLine
 	input   *Input
+	history *History
+	filter  *LineFilter
 	header  *InputComponent
 	file    *FileComponent
 	footer  *FooterComponent
+	picker  *ColumnPickerComponent
+	menu    *FilterMenuComponent
+
+	muColumns sync.Mutex
 }
 
 func NewScreen(lcfg *LoonConfig, reader Reader) (*Screen, error) {
@@ -47,61 +67,43 @@ func NewScreen(lcfg *LoonConfig, reader Reader) (*Screen, error) {
 	sources := reader.Sources()
 
 	// create parser
-	var parser Parser[Line]
-	{
-		if lcfg.NoAnsi {
-			parser = &RawParser{}
-		} else {
-			parser = &ANSIParser{
-				NoColor:     lcfg.NoColor,
-				SourceColor: len(sources) > 1 && lcfg.BgSourceColor,
-			}
-		}
+	parser, err := NewSourceParser(lcfg, sources, len(sources) > 1 && lcfg.BgSourceColor)
+	if err != nil {
+		return nil, err
 	}
 
 	// create input
 	input := &Input{}
 
-	// create filter
-	// @TODO: move this elsewhere
-	filter := func(l Line) (yes bool) {
-		inputs := strings.Split(input.Get(), " ")
-		marks := []Mark{}
-		lineLen := l.Len()
-		line := l.String()
-		for n, in := range inputs {
-			if len(in) == 0 {
-				l.SetMarks(marks...)
-				return true
-			}
-
-			for i := 0; i < lineLen; {
-				cutseq := line[i:]
-				index := strings.Index(cutseq, in)
-				if index < 0 {
-					break
-				}
+	// load filter history, errors are displayed in the header
+	history := NewHistory(lcfg.HistoryFile)
 
-				marks = append(marks, Mark{N: n, Off: index + i, Len: len(in)})
-				i += index + len(in)
-			}
+	// create filter
+	filter := NewLineFilter()
 
-		}
+	// create buffer
+	buffer := NewBuffer[Line](lcfg.RingSize)
 
-		l.SetMarks(marks...)
-		return len(marks) > 0
+	// create multiline rule
+	rule, err := NewMultilineRule(lcfg.Multiline)
+	if err != nil {
+		return nil, err
 	}
 
-	// create buffer
-	buffer := NewBuffer[Line](lcfg.RingSize)
+	// goroutine blocks are always folded
+	fold := rule.withGoroutines().Fold
 
 	// create buffer window
 	_, h := s.Size()
 	bw := NewBufferWindow(h, &BufferWindowOptions[Line]{
-		Reader: reader,
-		Filter: filter,
-		Parser: parser,
-		Buffer: buffer,
+		Reader:  reader,
+		Filter:  filter.Match,
+		Parser:  parser,
+		Buffer:  buffer,
+		Fold:    fold,
+		FoldKey: FoldKey,
+		Before:  lcfg.Before,
+		After:   lcfg.After,
 	})
 
 	// create printer
@@ -115,15 +117,23 @@ func NewScreen(lcfg *LoonConfig, reader Reader) (*Screen, error) {
 	}
 
 	filec := NewFileComponent(lcfg, printer, sources, input, bw)
-	inputc := NewInputComponent(lcfg, printer, input, 1, 0)
+	inputc := NewInputComponent(lcfg, printer, input, filter, bw, history, 1, 0)
 	footerc := NewFooterComponent(lcfg, s, printer, bw)
+	pickerc := NewColumnPickerComponent(printer)
+	menuc := NewFilterMenuComponent(printer, lcfg.Filters)
 	return &Screen{
+		lcfg:    lcfg,
+		sources: sources,
 		ts:      s,
 		bufferw: bw,
 		input:   input,
+		history: history,
+		filter:  filter,
 		header:  inputc,
 		file:    filec,
 		footer:  footerc,
+		picker:  pickerc,
+		menu:    menuc,
 
 		cupdate: make(chan struct{}, 1),
 	}, nil
@@ -172,6 +182,7 @@ func (s *Screen) Run() error {
 
 	go s.redrawLoop()
 	go s.readfile()
+	go s.relativeTimeLoop()
 
 	for {
 		switch ev := s.ts.PollEvent().(type) {
@@ -181,7 +192,7 @@ func (s *Screen) Run() error {
 			s.ts.Sync()
 			s.Redraw()
 		case *tcell.EventKey:
-			if ev.Key() == tcell.KeyEscape || ev.Key() == tcell.KeyCtrlC {
+			if s.isQuitKey(ev) {
 				s.ts.Fini()
 				return nil
 			}
@@ -194,6 +205,20 @@ func (s *Screen) Run() error {
 	}
 }
 
+// isQuitKey report if the key quit loon, escape close the column picker, the
+// filters menu and the history search first
+func (s *Screen) isQuitKey(ev *tcell.EventKey) bool {
+	switch ev.Key() {
+	case tcell.KeyCtrlC:
+		return true
+	case tcell.KeyEscape:
+		_, searching := s.history.Searching()
+		return !searching && !s.menu.IsOpen() && !s.picker.IsOpen()
+	default:
+		return false
+	}
+}
+
 func (s *Screen) handleEventMouse(ev *tcell.EventMouse) {
 	var cursor, offset int
 
@@ -218,16 +243,50 @@ func (s *Screen) handleEventMouse(ev *tcell.EventMouse) {
 }
 
 func (s *Screen) handleEventKey(ev *tcell.EventKey) error {
+	if s.picker.IsOpen() {
+		s.handlePickerKey(ev)
+		s.Redraw()
+		return nil
+	}
+
+	if s.menu.IsOpen() {
+		s.handleMenuKey(ev)
+		s.Redraw()
+		return nil
+	}
+
+	if _, ok := s.history.Searching(); ok {
+		s.handleSearchKey(ev)
+		s.Redraw()
+		return nil
+	}
+
 	var factor int
 	switch {
 	case ev.Modifiers()&tcell.ModCtrl != 0:
 		return s.handleCtrlCommand(ev)
+	case ev.Modifiers()&tcell.ModShift != 0 && ev.Key() == tcell.KeyUp:
+		s.bufferw.SelectAdd(1)
+		s.Redraw()
+		return nil
+	case ev.Modifiers()&tcell.ModShift != 0 && ev.Key() == tcell.KeyDown:
+		s.bufferw.SelectAdd(-1)
+		s.Redraw()
+		return nil
 	case ev.Modifiers()&tcell.ModAlt != 0:
 		factor = 5
 	default:
 		factor = 1
 	}
 
+	// plain up and down recall the history while editing the filter
+	if key := ev.Key(); factor == 1 && (key == tcell.KeyUp || key == tcell.KeyDown) &&
+		(s.input.Get() != "" || s.history.Recalling()) {
+		s.recallHistory(key == tcell.KeyUp)
+		s.Redraw()
+		return nil
+	}
+
 	switch ev.Key() {
 	case tcell.KeyUp:
 		s.file.CursorAdd(1 * factor)
@@ -239,13 +298,24 @@ func (s *Screen) handleEventKey(ev *tcell.EventKey) error {
 		s.file.OffsetAdd(-2 * factor)
 	case tcell.KeyBackspace, tcell.KeyBackspace2:
 		s.input.DeleteBackward()
+		s.history.ResetCursor()
+		s.updateFilter()
+	case tcell.KeyTab:
+		s.filter.NextMode()
 		s.bufferw.Refresh()
 	case tcell.KeyEnter:
+		s.history.Add(s.input.Get())
 		s.bufferw.MoveFront()
+	case tcell.KeyF1, tcell.KeyF2, tcell.KeyF3, tcell.KeyF4, tcell.KeyF5, tcell.KeyF6,
+		tcell.KeyF7, tcell.KeyF8, tcell.KeyF9, tcell.KeyF10, tcell.KeyF11, tcell.KeyF12:
+		if f, ok := savedFilterByKey(s.lcfg.Filters, ev.Key()); ok {
+			s.applySavedFilter(f)
+		}
 	default:
-		if r := ev.Rune(); (r >= 41 && r <= 176) || r == ' ' {
+		if r := ev.Rune(); ev.Key() == tcell.KeyRune && unicode.IsPrint(r) {
 			s.input.Add(r)
-			s.bufferw.Refresh()
+			s.history.ResetCursor()
+			s.updateFilter()
 			// s.file.ResetPosition()
 		} else {
 			return nil
@@ -264,6 +334,32 @@ func (s *Screen) handleCtrlCommand(ev *tcell.EventKey) error {
 		s.file.OffsetSet(0)
 	case tcell.KeyCtrlL:
 		s.Clear()
+	case tcell.KeyCtrlK:
+		s.filter.NextCaseMode()
+		s.bufferw.Refresh()
+	case tcell.KeyCtrlO:
+		s.toggleSorted()
+	case tcell.KeyCtrlT:
+		s.file.NextTimeDisplay()
+	case tcell.KeyCtrlB:
+		s.file.ToggleCollapsed()
+	case tcell.KeyCtrlG:
+		s.toggleGoroutines()
+	case tcell.KeyCtrlV:
+		s.openColumnPicker(s.displayedSource())
+	case tcell.KeyCtrlD:
+		s.filter.ToggleHighlight()
+		s.bufferw.Refresh()
+	case tcell.KeyCtrlX:
+		s.toggleExpanded()
+	case tcell.KeyCtrlP, tcell.KeyUp:
+		s.recallHistory(true)
+	case tcell.KeyCtrlN, tcell.KeyDown:
+		s.recallHistory(false)
+	case tcell.KeyCtrlR:
+		s.history.StartSearch(s.input.Get())
+	case tcell.KeyCtrlF:
+		s.menu.Open(s.input.Get())
 	default:
 	}
 
@@ -271,6 +367,235 @@ func (s *Screen) handleCtrlCommand(ev *tcell.EventKey) error {
 	return nil
 }
 
+// recallHistory replace the input with the previous query of the history,
+// or the next one
+func (s *Screen) recallHistory(prev bool) {
+	var query string
+	var ok bool
+	if prev {
+		query, ok = s.history.Prev(s.input.Get())
+	} else {
+		query, ok = s.history.Next()
+	}
+
+	if ok {
+		s.setFilter(query)
+	}
+}
+
+// handleSearchKey handle the keys of the reverse history search, the
+// matching query is applied as it's found
+func (s *Screen) handleSearchKey(ev *tcell.EventKey) {
+	var query string
+	var ok bool
+	switch ev.Key() {
+	case tcell.KeyCtrlR:
+		query, ok = s.history.SearchNext()
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		query, ok = s.history.SearchDeleteBackward()
+	case tcell.KeyEnter:
+		s.history.StopSearch()
+		s.history.Add(s.input.Get())
+		s.bufferw.MoveFront()
+	case tcell.KeyEscape:
+		s.setFilter(s.history.StopSearch())
+	default:
+		if r := ev.Rune(); ev.Key() == tcell.KeyRune && unicode.IsPrint(r) {
+			query, ok = s.history.SearchAdd(r)
+		}
+	}
+
+	if ok {
+		s.setFilter(query)
+	}
+}
+
+func (s *Screen) handleMenuKey(ev *tcell.EventKey) {
+	switch ev.Key() {
+	case tcell.KeyCtrlF, tcell.KeyEscape:
+		s.menu.Close()
+	case tcell.KeyUp:
+		s.menu.CursorAdd(1)
+	case tcell.KeyDown:
+		s.menu.CursorAdd(-1)
+	case tcell.KeyEnter:
+		if f, ok := s.menu.Selected(); ok {
+			s.applySavedFilter(f)
+		}
+		s.menu.Close()
+	default:
+		if f, ok := savedFilterByKey(s.lcfg.Filters, ev.Key()); ok {
+			s.applySavedFilter(f)
+			s.menu.Close()
+		}
+	}
+}
+
+// applySavedFilter replace the input with the saved query, committed to the
+// history
+func (s *Screen) applySavedFilter(f SavedFilter) {
+	s.setFilter(f.Query)
+	s.history.Add(f.Query)
+	s.bufferw.MoveFront()
+}
+
+// displayedSource return the source of the newest displayed line
+func (s *Screen) displayedSource() File {
+	var sid SourceID
+	if lines := s.bufferw.Slice(); len(lines) > 0 {
+		sid = lines[len(lines)-1].Source()
+	}
+
+	for _, f := range s.sources {
+		if f.ID == sid {
+			return f
+		}
+	}
+
+	return s.sources[0]
+}
+
+// openColumnPicker open the column picker on the fields of the given
+// source
+func (s *Screen) openColumnPicker(f File) {
+	var fields []string
+	s.bufferw.DoLive(func(buffer *Buffer[Line]) {
+		fields = sourceFields(buffer, f.ID)
+	})
+
+	s.picker.Open(f, fields, s.file.Columns(f.ID))
+}
+
+func (s *Screen) handlePickerKey(ev *tcell.EventKey) {
+	switch ev.Key() {
+	case tcell.KeyCtrlV, tcell.KeyEscape:
+		s.picker.Close()
+	case tcell.KeyUp:
+		if ev.Modifiers()&tcell.ModAlt != 0 {
+			s.picker.Move(1)
+		} else {
+			s.picker.CursorAdd(1)
+		}
+	case tcell.KeyDown:
+		if ev.Modifiers()&tcell.ModAlt != 0 {
+			s.picker.Move(-1)
+		} else {
+			s.picker.CursorAdd(-1)
+		}
+	case tcell.KeyTab:
+		// next source
+		source := s.picker.Source()
+		for i, f := range s.sources {
+			if f.ID == source.ID {
+				s.openColumnPicker(s.sources[(i+1)%len(s.sources)])
+				break
+			}
+		}
+	case tcell.KeyEnter:
+		s.saveColumns(s.picker.Columns())
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		s.saveColumns(nil)
+	default:
+		if ev.Rune() == ' ' {
+			s.picker.Toggle()
+		}
+	}
+}
+
+// saveColumns display the given columns for the source of the picker and
+// save them in the config file
+func (s *Screen) saveColumns(columns []string) {
+	s.muColumns.Lock()
+	defer s.muColumns.Unlock()
+
+	source := s.picker.Source()
+	s.file.SetColumns(source.ID, columns)
+	if err := saveColumnsFile(s.lcfg, source, columns); err != nil {
+		s.picker.SetErr(err)
+		return
+	}
+
+	s.picker.Close()
+}
+
+// toggleExpanded display the unfiltered buffer centered on the selected
+// line, or the newest line without selection. The filtered view is restored
+// as it was left on the next toggle
+func (s *Screen) toggleExpanded() {
+	if s.bufferw.Expanded() {
+		s.bufferw.Collapse()
+		return
+	}
+
+	s.bufferw.Expand()
+}
+
+// toggleSorted display a snapshot of the buffer lines matching the filter,
+// sorted by score with the best match at the bottom
+func (s *Screen) toggleSorted() {
+	if s.bufferw.SnapshotName() != "" {
+		s.bufferw.ClearSnapshot()
+		return
+	}
+
+	type scoredLine struct {
+		line  Line
+		score int
+	}
+
+	scored := []scoredLine{}
+	s.bufferw.DoLive(func(buffer *Buffer[Line]) {
+		buffer.DoPrev(func(_ *ring.Ring, l Line) bool {
+			if score, ok := s.filter.Score(l); ok {
+				scored = append(scored, scoredLine{l, score})
+			}
+			return true
+		})
+	})
+
+	// keep chronological order on equal score
+	lines := make([]Line, len(scored))
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].score > scored[j].score
+	})
+	for i, sl := range scored {
+		lines[len(lines)-i-1] = sl.line
+	}
+
+	s.bufferw.Snapshot("sorted", lines)
+}
+
+// toggleGoroutines display a snapshot summarizing the goroutines matching
+// the filter by state and top frame
+func (s *Screen) toggleGoroutines() {
+	if s.bufferw.SnapshotName() != "" {
+		s.bufferw.ClearSnapshot()
+		return
+	}
+
+	records := []*Record{}
+	s.bufferw.DoLive(func(buffer *Buffer[Line]) {
+		buffer.DoPrev(func(_ *ring.Ring, l Line) bool {
+			if r, ok := l.(*Record); ok && s.filter.Match(r) {
+				records = append(records, r)
+			}
+			return true
+		})
+	})
+
+	s.bufferw.Snapshot("goroutines", GoroutineSummary(records))
+}
+
+func (s *Screen) setFilter(query string) {
+	s.input.Set(query)
+	s.updateFilter()
+}
+
+func (s *Screen) updateFilter() {
+	s.filter.Update(s.input.Get())
+	s.bufferw.Refresh()
+}
+
 func (s *Screen) Redraw() {
 	select {
 	case s.cupdate <- struct{}{}:
@@ -278,6 +603,18 @@ func (s *Screen) Redraw() {
 	}
 }
 
+// relativeTimeLoop keep relative timestamps up to date
+func (s *Screen) relativeTimeLoop() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if s.file.TimeDisplay() == TimeDisplayRelative {
+			s.Redraw()
+		}
+	}
+}
+
 func (s *Screen) redrawLoop() {
 	for range s.cupdate {
 		s.redraw()
@@ -293,7 +630,14 @@ func (s *Screen) redraw() {
 	s.header.Redraw(1, 0, w, 1)
 
 	// file start at x:1, y:1
-	s.file.Redraw(0, 1, w, h-2)
+	switch {
+	case s.picker.IsOpen():
+		s.picker.Redraw(0, 1, w, h-2)
+	case s.menu.IsOpen():
+		s.menu.Redraw(0, 1, w, h-2)
+	default:
+		s.file.Redraw(0, 1, w, h-2)
+	}
 
 	// file start at x:1, y:1
 	s.footer.Redraw(1, h-1, w, 1)
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999 -0700",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05,999999999", // log4j, python
+	"2006/01/02 15:04:05.999999999", // go log
+	"02/Jan/2006:15:04:05 -0700",    // common log format
+	time.RFC1123Z,
+	time.RFC1123,
+	time.Stamp, // syslog
+	time.StampMicro,
+}
+
+// ParseTime parse a timestamp value using common layouts, or as an epoch in
+// seconds, milliseconds, microseconds or nanoseconds. Timestamps without
+// time zone are considered local, timestamps without year are considered
+// from the current year.
+func ParseTime(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	if t, ok := parseEpoch(value); ok {
+		return t, true
+	}
+
+	for _, layout := range timeLayouts {
+		t, err := time.ParseInLocation(layout, value, time.Local)
+		if err != nil {
+			continue
+		}
+
+		if t.Year() == 0 {
+			t = t.AddDate(time.Now().Year(), 0, 0)
+		}
+
+		return t, true
+	}
+
+	return time.Time{}, false
+}
+
+// parseEpoch guess the unit of an epoch timestamp from its number of
+// digits, only timestamps between 2000 and 2100 are considered
+func parseEpoch(value string) (time.Time, bool) {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n <= 0 {
+		return time.Time{}, false
+	}
+
+	digits := len(value)
+	if i := strings.IndexByte(value, '.'); i >= 0 {
+		digits = i
+	}
+
+	// integers are parsed as is to keep their precision
+	i, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		i = int64(n)
+	}
+
+	var t time.Time
+	switch digits {
+	case 9, 10:
+		sec, frac := math.Modf(n)
+		t = time.Unix(int64(sec), int64(frac*1e9))
+	case 12, 13:
+		t = time.UnixMilli(i)
+	case 15, 16:
+		t = time.UnixMicro(i)
+	case 18, 19:
+		t = time.Unix(0, i)
+	default:
+		return time.Time{}, false
+	}
+
+	if y := t.Year(); y < 2000 || y > 2100 {
+		return time.Time{}, false
+	}
+
+	return t, true
+}
+
+// only the beginning of lines is scanned for timestamps
+const timeDetectSize = 128
+
+var timeDetectRegexp = regexp.MustCompile(
+	// iso8601, rfc3339
+	`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2}| [+-]\d{4})?` +
+		// go log
+		`|\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}(?:\.\d+)?` +
+		// common log format
+		`|\d{2}/[A-Z][a-z]{2}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}` +
+		// syslog
+		`|(?:Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec) [ \d]\d \d{2}:\d{2}:\d{2}(?:\.\d+)?` +
+		// epoch at the beginning of the line, seconds or milliseconds between
+		// 2014 and 2033, other numbers are more likely ids or counters
+		`|^1[4-9]\d{8}(?:\.\d+)?\b|^1[4-9]\d{11}\b`,
+)
+
+// DetectTime look for a timestamp in the given line, and return its
+// position
+func DetectTime(line string) (t time.Time, off, size int) {
+	if len(line) > timeDetectSize {
+		line = line[:timeDetectSize]
+	}
+
+	loc := timeDetectRegexp.FindStringIndex(line)
+	if loc == nil {
+		return time.Time{}, -1, 0
+	}
+
+	t, ok := ParseTime(line[loc[0]:loc[1]])
+	if !ok {
+		return time.Time{}, -1, 0
+	}
+
+	return t, loc[0], loc[1] - loc[0]
+}
+
+type TimeDisplay int
+
+const (
+	// TimeDisplayOriginal keep timestamps as they are
+	TimeDisplayOriginal TimeDisplay = iota
+	TimeDisplayLocal
+	TimeDisplayUTC
+	TimeDisplayRelative
+
+	timeDisplayCount
+)
+
+const timeDisplayLayout = "2006-01-02 15:04:05.000"
+
+// Width return the width of the time column
+func (d TimeDisplay) Width() int {
+	switch d {
+	case TimeDisplayLocal, TimeDisplayUTC:
+		return len(timeDisplayLayout)
+	case TimeDisplayRelative:
+		return len("59m ahead")
+	default:
+		return 0
+	}
+}
+
+func (d TimeDisplay) Format(t time.Time, now time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	switch d {
+	case TimeDisplayLocal:
+		return t.Local().Format(timeDisplayLayout)
+	case TimeDisplayUTC:
+		return t.UTC().Format(timeDisplayLayout)
+	case TimeDisplayRelative:
+		return formatRelativeTime(now.Sub(t))
+	default:
+		return ""
+	}
+}
+
+func formatRelativeTime(d time.Duration) string {
+	suffix := " ago"
+	if d < 0 {
+		d, suffix = -d, " ahead"
+	}
+
+	switch {
+	case d < time.Second:
+		return "now"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds%s", d/time.Second, suffix)
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%s", d/time.Minute, suffix)
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%s", d/time.Hour, suffix)
+	default:
+		return fmt.Sprintf("%dd%s", d/(24*time.Hour), suffix)
+	}
+}
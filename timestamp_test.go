@@ -0,0 +1,118 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectTime(t *testing.T) {
+	local := func(year int, month time.Month, day, hour, min, sec, nsec int) time.Time {
+		return time.Date(year, month, day, hour, min, sec, nsec, time.Local)
+	}
+
+	cases := []struct {
+		Line  string
+		Time  time.Time
+		Token string
+	}{
+		{"nothing to see here", time.Time{}, ""},
+		{"took 12:00:00 to complete", time.Time{}, ""},
+		{"2022-01-02T15:04:05Z started", time.Date(2022, 1, 2, 15, 4, 5, 0, time.UTC), "2022-01-02T15:04:05Z"},
+		{"[2022-01-02T15:04:05.123+02:00] started", time.Date(2022, 1, 2, 13, 4, 5, 123e6, time.UTC), "2022-01-02T15:04:05.123+02:00"},
+		{"2022-01-02 15:04:05,123 INFO started", local(2022, 1, 2, 15, 4, 5, 123e6), "2022-01-02 15:04:05,123"},
+		{"2022/01/02 15:04:05 started", local(2022, 1, 2, 15, 4, 5, 0), "2022/01/02 15:04:05"},
+		{"2022/01/02 15:04:05.000123 started", local(2022, 1, 2, 15, 4, 5, 123e3), "2022/01/02 15:04:05.000123"},
+		{`127.0.0.1 - - [02/Jan/2022:15:04:05 +0000] "GET /"`, time.Date(2022, 1, 2, 15, 4, 5, 0, time.UTC), "02/Jan/2022:15:04:05 +0000"},
+		{"<11>Jan  2 15:04:05 host app: failed", local(time.Now().Year(), 1, 2, 15, 4, 5, 0), "Jan  2 15:04:05"},
+		{"1641135845 started", time.Unix(1641135845, 0), "1641135845"},
+		{"1641135845123 started", time.UnixMilli(1641135845123), "1641135845123"},
+		{"12345 started", time.Time{}, ""},
+		{"1234567890 calls", time.Time{}, ""},
+		{"2147483647 max", time.Time{}, ""},
+		{"1234567890123 bytes", time.Time{}, ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Line, func(t *testing.T) {
+			ts, off, size := DetectTime(tc.Line)
+			require.True(t, tc.Time.Equal(ts), "expected %s, got %s", tc.Time, ts)
+			if !tc.Time.IsZero() {
+				require.Equal(t, tc.Token, tc.Line[off:off+size])
+			}
+		})
+	}
+}
+
+func TestParseTimeEpoch(t *testing.T) {
+	cases := map[string]time.Time{
+		"1641135845":          time.Unix(1641135845, 0),
+		"1641135845.5":        time.Unix(1641135845, 5e8),
+		"1641135845123":       time.UnixMilli(1641135845123),
+		"1641135845123456":    time.UnixMicro(1641135845123456),
+		"1641135845123456789": time.Unix(0, 1641135845123456789),
+	}
+
+	for value, expected := range cases {
+		ts, ok := ParseTime(value)
+		require.True(t, ok, value)
+		require.True(t, expected.Equal(ts), "%s: expected %s, got %s", value, expected, ts)
+	}
+
+	for _, value := range []string{"", "42", "-1641135845", "99999999999", "abc"} {
+		_, ok := ParseTime(value)
+		require.False(t, ok, value)
+	}
+}
+
+func TestLineTime(t *testing.T) {
+	expected := time.Date(2022, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	t.Run("json", func(t *testing.T) {
+		parser := &JSONParser{Fallback: &RawParser{}}
+		l := parser.Parse(0, `{"msg": "started", "ts": 1641135845}`)
+		ts, off, size := l.Time()
+		require.True(t, expected.Equal(ts))
+		require.Equal(t, "1641135845", l.String()[off:off+size])
+	})
+
+	t.Run("logfmt", func(t *testing.T) {
+		parser := &LogfmtParser{Fallback: &RawParser{}}
+		l := parser.Parse(0, `msg=started time=2022-01-02T15:04:05Z`)
+		ts, off, _ := l.Time()
+		require.True(t, expected.Equal(ts))
+		require.Equal(t, 0, off)
+	})
+
+	t.Run("no time", func(t *testing.T) {
+		parser := &LogfmtParser{Fallback: &RawParser{}}
+		ts, _, _ := parser.Parse(0, `msg=started time=yesterday`).Time()
+		require.True(t, ts.IsZero())
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		ts, off, _ := ParseRawLine(0, "[2022-01-02T15:04:05Z] started").Time()
+		require.True(t, expected.Equal(ts))
+		require.Equal(t, 1, off)
+	})
+}
+
+func TestFormatRelativeTime(t *testing.T) {
+	now := time.Date(2022, 1, 2, 15, 4, 5, 0, time.UTC)
+	cases := map[time.Duration]string{
+		0:                             "now",
+		12 * time.Second:              "12s ago",
+		3*time.Minute + 2*time.Second: "3m ago",
+		2*time.Hour + 5*time.Minute:   "2h ago",
+		50 * time.Hour:                "2d ago",
+		-3 * time.Second:              "3s ahead",
+	}
+
+	for d, expected := range cases {
+		require.Equal(t, expected, TimeDisplayRelative.Format(now.Add(-d), now))
+	}
+
+	require.Equal(t, "", TimeDisplayRelative.Format(time.Time{}, now))
+	require.Equal(t, "2022-01-02 15:04:05.000", TimeDisplayUTC.Format(now, now))
+}